@@ -0,0 +1,71 @@
+package configinator
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+CacheSource wraps a Source, keeping the values it loads for the TTL.
+Loading config again within the TTL, such as on a periodic refresh,
+reuses them rather than hitting the backend again. Values are copied,
+so changes made by the caller don't reach the cache.
+
+	configinator.WithSource(configinator.CacheSource(metadata, 10*time.Minute))
+*/
+func CacheSource(source Source, ttl time.Duration) Source {
+	return &cachedSource{
+		source: source,
+		ttl:    ttl,
+	}
+}
+
+type cachedSource struct {
+	source Source
+	ttl    time.Duration
+
+	lock    sync.Mutex
+	values  map[string]string
+	fetched time.Time
+}
+
+func (s *cachedSource) Name() string {
+	return s.source.Name()
+}
+
+func (s *cachedSource) Load(ctx context.Context) (map[string]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.values == nil || time.Since(s.fetched) >= s.ttl {
+		values, err := s.source.Load(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.values = values
+		s.fetched = time.Now()
+	}
+
+	result := make(map[string]string, len(s.values))
+
+	for key, value := range s.values {
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+/*
+Watch passes through to the wrapped source, when it is a Watcher.
+*/
+func (s *cachedSource) Watch(ctx context.Context, changed func()) error {
+	if watcher, ok := s.source.(Watcher); ok {
+		return watcher.Watch(ctx, changed)
+	}
+
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,85 @@
+package configinator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"reflect"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+commandFields returns the subcommand fields of a config struct, by
+command name. These are struct fields, or pointers to structs, with a
+cmd tag.
+*/
+func commandFields(config reflect.Value, files container.Files) map[string]int {
+	result := make(map[string]int)
+	t := config.Type()
+
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		name, ok := files.LookupTag(field, container.TagCommand)
+
+		if !ok || name == "" || !field.IsExported() {
+			continue
+		}
+
+		fieldType := field.Type
+
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct {
+			result[name] = index
+		}
+	}
+
+	return result
+}
+
+/*
+loadCommand loads the subcommand named by the first argument left after
+parsing flags, such as serve in "mytool -debug serve -port 8080". Its
+struct gets its own flag set, parsed from the arguments after the
+command name, and is otherwise set like the config struct. Pointer
+fields are only allocated for the command that runs, so a nil pointer
+means its command wasn't given.
+*/
+func loadCommand(ctx context.Context, o *options, files container.Files, config reflect.Value) error {
+	commands := commandFields(config, files)
+	args := o.flags.Args()
+
+	if len(commands) == 0 || len(args) == 0 {
+		return nil
+	}
+
+	index, ok := commands[args[0]]
+
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	field := config.Type().Field(index)
+	value := config.Field(index)
+
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+
+		value = value.Elem()
+	}
+
+	command := *o
+	command.flags = flag.NewFlagSet(args[0], o.flags.ErrorHandling())
+	command.flags.SetOutput(o.flags.Output())
+	command.args = args[1:]
+	command.configFlag = ""
+	command.version = nil
+	command.prefix = joinPrefix(o.prefix, files.Tag(field, container.TagPrefix))
+
+	return load(ctx, &command, files, value)
+}
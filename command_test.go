@@ -0,0 +1,82 @@
+package configinator
+
+import (
+	"testing"
+)
+
+func TestSubcommands(t *testing.T) {
+	type serveCmd struct {
+		Port int `flag:"port" env:"PORT" default:"80"`
+	}
+
+	type migrateCmd struct {
+		Steps int    `flag:"steps" required:"true"`
+		Dir   string `flag:"dir" default:"migrations"`
+	}
+
+	type config struct {
+		Debug   bool        `flag:"debug"`
+		Serve   *serveCmd   `cmd:"serve"`
+		Migrate *migrateCmd `cmd:"migrate"`
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		check func(c config) bool
+		err   bool
+	}{
+		{name: "no command", args: []string{"-debug"}, check: func(c config) bool {
+			return c.Debug && c.Serve == nil && c.Migrate == nil
+		}},
+		{name: "command flags", args: []string{"-debug", "serve", "-port", "8080"}, check: func(c config) bool {
+			return c.Debug && c.Serve != nil && c.Serve.Port == 8080 && c.Migrate == nil
+		}},
+		{name: "command default", args: []string{"serve"}, check: func(c config) bool {
+			return c.Serve != nil && c.Serve.Port == 80
+		}},
+		{name: "command env", args: []string{"serve"}, env: map[string]string{"PORT": "9000"}, check: func(c config) bool {
+			return c.Serve != nil && c.Serve.Port == 9000
+		}},
+		{name: "other command's required field", args: []string{"serve"}, check: func(c config) bool {
+			return c.Migrate == nil
+		}},
+		{name: "required field", args: []string{"migrate"}, err: true},
+		{name: "second command", args: []string{"migrate", "-steps", "3"}, check: func(c config) bool {
+			return c.Migrate != nil && c.Migrate.Steps == 3 && c.Migrate.Dir == "migrations" && c.Serve == nil
+		}},
+		{name: "command flag before command", args: []string{"-port", "8080", "serve"}, err: true},
+		{name: "unknown command", args: []string{"deploy"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			err := beholdArgs(&got, test.args...)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.check(got) {
+				t.Errorf("unexpected config %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported file formats
+const (
+	FormatCUE     string = "cue"
+	FormatHCL     string = "hcl"
+	FormatJSON    string = "json"
+	FormatJsonnet string = "jsonnet"
+	FormatTFVars  string = "tfvars"
+	FormatXML     string = "xml"
+	FormatYAML    string = "yaml"
+)
+
+/*
+Values holds the contents of a structured config file, flattened so
+that nested keys are joined with a dash. For example, the YAML document
+
+	database:
+	  host: localhost
+
+has a value for "database" (the nested map) and "database-host". The
+same goes for JSON objects. Items in lists of maps are addressed by
+index, such as "upstreams-0-host". A list holding a single map is also
+addressed without the index, as that is how HCL decodes blocks. Keys
+are lower cased.
+*/
+type Values struct {
+	Format string
+	values map[string]interface{}
+}
+
+/*
+New flattens a decoded document into Values.
+*/
+func New(format string, document interface{}) Values {
+	result := Values{
+		Format: format,
+		values: make(map[string]interface{}),
+	}
+
+	flatten("", normalize(document), result.values)
+	return result
+}
+
+/*
+Parse decodes a config document, choosing the format from the file
+name's extension. CUE and Jsonnet are evaluated by tools that need the
+file on disk, so they can't be parsed from memory.
+*/
+func Parse(fileName string, data []byte) (Values, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".hcl":
+		return ParseHCL(data)
+
+	case ".json", ".json5":
+		return ParseJSON(data)
+
+	case ".tfvars":
+		return ParseTFVars(data)
+
+	case ".xml":
+		return ParseXML(data)
+
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	}
+
+	return Values{}, fmt.Errorf("unsupported config file format '%s'", fileName)
+}
+
+/*
+ParseFormat decodes a config document in the named format, such as
+FormatYAML. This is for documents without a file name, like those read
+from stdin.
+*/
+func ParseFormat(format string, data []byte) (Values, error) {
+	switch format {
+	case FormatHCL:
+		return ParseHCL(data)
+
+	case FormatJSON:
+		return ParseJSON(data)
+
+	case FormatTFVars:
+		return ParseTFVars(data)
+
+	case FormatXML:
+		return ParseXML(data)
+
+	case FormatYAML:
+		return ParseYAML(data)
+	}
+
+	return Values{}, fmt.Errorf("unsupported config format '%s'", format)
+}
+
+/*
+DetectFormat guesses the format of a config document from its first
+character. Documents starting with < are XML, and those starting with {
+or [ are JSON. Anything else is taken to be YAML.
+*/
+func DetectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+
+	if len(trimmed) == 0 {
+		return FormatYAML
+	}
+
+	switch trimmed[0] {
+	case '<':
+		return FormatXML
+
+	case '{', '[':
+		return FormatJSON
+	}
+
+	return FormatYAML
+}
+
+/*
+ReadFS reads a config file from a file system, such as one embedded
+with go:embed.
+*/
+func ReadFS(fsys fs.FS, fileName string) (Values, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	if data, err = fs.ReadFile(fsys, fileName); err != nil {
+		return Values{}, err
+	}
+
+	return Parse(fileName, data)
+}
+
+/*
+Read reads a config file, choosing the format from the file's extension.
+Jsonnet files are evaluated without external variables. Use ReadJsonnet
+to pass them. Files encrypted with SOPS are decrypted.
+*/
+func Read(fileName string) (Values, error) {
+	return ReadContext(context.Background(), fileName)
+}
+
+/*
+ReadContext reads a config file like Read. The context kills the tools
+that evaluate CUE and Jsonnet files, and decrypt SOPS files, if it is
+cancelled first.
+*/
+func ReadContext(ctx context.Context, fileName string) (Values, error) {
+	values, err := read(ctx, fileName)
+
+	if err != nil || !values.IsSOPS() {
+		return values, err
+	}
+
+	return ReadSOPSContext(ctx, fileName)
+}
+
+func read(ctx context.Context, fileName string) (Values, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".cue":
+		return ReadCUEContext(ctx, fileName)
+
+	case ".hcl":
+		return ReadHCL(fileName)
+
+	case ".json", ".json5":
+		return ReadJSON(fileName)
+
+	case ".jsonnet":
+		return ReadJsonnetContext(ctx, fileName, nil)
+
+	case ".tfvars":
+		return ReadTFVars(fileName)
+
+	case ".xml":
+		return ReadXML(fileName)
+
+	case ".yaml", ".yml":
+		return ReadYAML(fileName)
+	}
+
+	return Values{}, fmt.Errorf("unsupported config file format '%s'", fileName)
+}
+
+/*
+Keys returns all keys, sorted.
+*/
+func (v Values) Keys() []string {
+	result := make([]string, 0, len(v.values))
+
+	for key := range v.values {
+		result = append(result, key)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+/*
+Lookup returns the value for a key, and true if it is present.
+Values are scalars, []interface{}, or map[string]interface{}.
+*/
+func (v Values) Lookup(key string) (interface{}, bool) {
+	value, ok := v.values[v.key(key)]
+	return value, ok && value != nil
+}
+
+/*
+Rename returns a copy of the values with keys moved. Keys maps each new
+key to the key its value is found under. An empty key removes the new
+key, so a field with a yaml tag of "-" isn't read from YAML files.
+Config files are renamed to flag names when read, so files of
+different formats can be merged.
+*/
+func (v Values) Rename(keys map[string]string) Values {
+	result := Values{
+		Format: v.Format,
+		values: make(map[string]interface{}, len(v.values)),
+	}
+
+	for key, value := range v.values {
+		result.values[key] = value
+	}
+
+	for to, from := range keys {
+		if v.key(to) != v.key(from) {
+			delete(result.values, v.key(to))
+		}
+	}
+
+	for to, from := range keys {
+		if from == "" || v.key(to) == v.key(from) {
+			continue
+		}
+
+		if value, ok := v.values[v.key(from)]; ok {
+			result.values[v.key(to)] = value
+		}
+	}
+
+	return result
+}
+
+func (v Values) key(key string) string {
+	key = strings.ToLower(key)
+
+	if v.Format == FormatTFVars {
+		key = tfvarsKey(key)
+	}
+
+	return key
+}
+
+func flatten(prefix string, node interface{}, result map[string]interface{}) {
+	if prefix != "" {
+		result[prefix] = node
+	}
+
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		for key, value := range typed {
+			flatten(joinKey(prefix, strings.ToLower(key)), value, result)
+		}
+
+	case []interface{}:
+		for index, value := range typed {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				flatten(joinKey(prefix, strconv.Itoa(index)), value, result)
+			}
+		}
+
+		if single, ok := singleMap(typed); ok && prefix != "" {
+			for key, value := range single {
+				flatten(joinKey(prefix, strings.ToLower(key)), value, result)
+			}
+		}
+	}
+}
+
+func singleMap(list []interface{}) (map[string]interface{}, bool) {
+	if len(list) != 1 {
+		return nil, false
+	}
+
+	result, ok := list[0].(map[string]interface{})
+	return result, ok
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "-" + key
+}
+
+/*
+normalize converts maps with non-string keys, and typed slices and maps,
+which some decoders produce, into []interface{} and map[string]interface{}.
+*/
+func normalize(node interface{}) interface{} {
+	if node == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(node)
+
+	switch v.Kind() {
+	case reflect.Map:
+		result := make(map[string]interface{}, v.Len())
+		iterator := v.MapRange()
+
+		for iterator.Next() {
+			result[fmt.Sprint(iterator.Key().Interface())] = normalize(iterator.Value().Interface())
+		}
+
+		return result
+
+	case reflect.Slice:
+		result := make([]interface{}, v.Len())
+
+		for index := 0; index < v.Len(); index++ {
+			result[index] = normalize(v.Index(index).Interface())
+		}
+
+		return result
+	}
+
+	return node
+}
+
+/*
+Merge combines several Values in order, with keys in later Values
+overriding earlier ones. This overlays, for example, an environment's
+config file on a base file. Empty Values are ignored. The format is
+kept when all share one, and is otherwise empty. Rename each Values to
+flag names first, so keys from tags such as yaml match across formats.
+*/
+func Merge(all ...Values) Values {
+	result := Values{
+		values: make(map[string]interface{}),
+	}
+
+	first := true
+
+	for _, values := range all {
+		if values.Format == "" && len(values.values) == 0 {
+			continue
+		}
+
+		if first {
+			result.Format = values.Format
+			first = false
+		} else if values.Format != result.Format {
+			result.Format = ""
+		}
+
+		for key, value := range values.values {
+			result.values[key] = value
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,42 @@
+package configfile
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+ReadCUE evaluates a CUE file using the cue command line tool
+(https://cuelang.org), which must be in your PATH. The file is exported
+to JSON, so values are checked against the constraints in the file
+before they are used. A value that violates a constraint, or a field
+that is left incomplete, is an error. For example:
+
+	port: int & >=1 & <=65535
+	port: 8080
+*/
+func ReadCUE(fileName string) (Values, error) {
+	return ReadCUEContext(context.Background(), fileName)
+}
+
+/*
+ReadCUEContext evaluates a CUE file like ReadCUE, killing the cue tool
+if the context is cancelled first.
+*/
+func ReadCUEContext(ctx context.Context, fileName string) (Values, error) {
+	var (
+		err      error
+		output   []byte
+		document interface{}
+	)
+
+	if output, err = RunToolContext(ctx, "cue", "export", "--out", "json", fileName); err != nil {
+		return Values{}, fmt.Errorf("error evaluating CUE: %w", err)
+	}
+
+	if document, err = decodeJSON(output); err != nil {
+		return Values{}, err
+	}
+
+	return New(FormatCUE, document), nil
+}
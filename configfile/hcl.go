@@ -0,0 +1,47 @@
+package configfile
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl"
+)
+
+/*
+ParseHCL decodes an HCL document. Blocks, such as
+
+	database {
+	  host = "localhost"
+	}
+
+are matched the same as nested maps, so the above has a value for
+"database-host".
+*/
+func ParseHCL(data []byte) (Values, error) {
+	var (
+		err      error
+		document interface{}
+	)
+
+	if err = hcl.Unmarshal(data, &document); err != nil {
+		return Values{}, fmt.Errorf("error parsing HCL: %w", err)
+	}
+
+	return New(FormatHCL, document), nil
+}
+
+/*
+ReadHCL reads and decodes an HCL file.
+*/
+func ReadHCL(fileName string) (Values, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	if data, err = os.ReadFile(fileName); err != nil {
+		return Values{}, err
+	}
+
+	return ParseHCL(data)
+}
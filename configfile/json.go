@@ -0,0 +1,63 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+/*
+ParseJSON decodes a JSON document. Comments, trailing commas, single
+quoted strings, and unquoted keys from JSON5 are accepted, as hand
+edited config files are rarely strict JSON. Numbers are kept as
+written, so large integers don't lose precision.
+*/
+func ParseJSON(data []byte) (Values, error) {
+	var (
+		err      error
+		document interface{}
+	)
+
+	if document, err = decodeJSON(data); err != nil {
+		return Values{}, err
+	}
+
+	return New(FormatJSON, document), nil
+}
+
+/*
+ReadJSON reads and decodes a JSON file.
+*/
+func ReadJSON(fileName string) (Values, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	if data, err = os.ReadFile(fileName); err != nil {
+		return Values{}, err
+	}
+
+	return ParseJSON(data)
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	var (
+		err      error
+		document interface{}
+	)
+
+	if data, err = json5ToJSON(data); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err = decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+
+	return document, nil
+}
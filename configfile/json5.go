@@ -0,0 +1,174 @@
+package configfile
+
+import (
+	"bytes"
+	"fmt"
+)
+
+/*
+json5ToJSON rewrites the parts of JSON5 that hand edited config files
+tend to use into strict JSON. This covers // and block comments, trailing
+commas, single quoted strings, and unquoted keys. Anything else is
+passed through for the JSON decoder to judge.
+*/
+func json5ToJSON(data []byte) ([]byte, error) {
+	var (
+		result bytes.Buffer
+	)
+
+	for index := 0; index < len(data); index++ {
+		c := data[index]
+
+		switch {
+		case c == '"' || c == '\'':
+			end, err := copyString(data, index, &result)
+
+			if err != nil {
+				return nil, err
+			}
+
+			index = end
+
+		case c == '/' && index+1 < len(data) && (data[index+1] == '/' || data[index+1] == '*'):
+			end, err := skipComment(data, index)
+
+			if err != nil {
+				return nil, err
+			}
+
+			index = end
+
+		case c == ',':
+			next := skipSpace(data, index+1)
+
+			if next < len(data) && (data[next] == '}' || data[next] == ']') {
+				continue
+			}
+
+			result.WriteByte(c)
+
+		case isIdentifierStart(c):
+			end := index
+
+			for end < len(data) && isIdentifierPart(data[end]) {
+				end++
+			}
+
+			identifier := data[index:end]
+			next := skipSpace(data, end)
+
+			if next < len(data) && data[next] == ':' {
+				result.WriteByte('"')
+				result.Write(identifier)
+				result.WriteByte('"')
+			} else {
+				result.Write(identifier)
+			}
+
+			index = end - 1
+
+		default:
+			result.WriteByte(c)
+		}
+	}
+
+	return result.Bytes(), nil
+}
+
+/*
+copyString copies a quoted string starting at index, converting single
+quoted strings to double quoted. JSON5 allows \' in either kind of
+string, which JSON doesn't, so it is written as a plain quote. It
+returns the index of the closing quote.
+*/
+func copyString(data []byte, index int, result *bytes.Buffer) (int, error) {
+	quote := data[index]
+	result.WriteByte('"')
+
+	for position := index + 1; position < len(data); position++ {
+		c := data[position]
+
+		switch {
+		case c == '\\' && position+1 < len(data):
+			if data[position+1] == '\'' {
+				result.WriteByte('\'')
+			} else {
+				result.WriteByte(c)
+				result.WriteByte(data[position+1])
+			}
+
+			position++
+
+		case c == quote:
+			result.WriteByte('"')
+			return position, nil
+
+		case c == '"':
+			result.WriteString(`\"`)
+
+		default:
+			result.WriteByte(c)
+		}
+	}
+
+	return len(data), fmt.Errorf("unterminated string")
+}
+
+/*
+skipComment returns the index of the last character of the comment
+starting at index.
+*/
+func skipComment(data []byte, index int) (int, error) {
+	if data[index+1] == '/' {
+		end := bytes.IndexByte(data[index:], '\n')
+
+		if end == -1 {
+			return len(data) - 1, nil
+		}
+
+		return index + end - 1, nil
+	}
+
+	end := bytes.Index(data[index+2:], []byte("*/"))
+
+	if end == -1 {
+		return len(data), fmt.Errorf("unterminated comment")
+	}
+
+	return index + 2 + end + 1, nil
+}
+
+/*
+skipSpace returns the index of the next character that isn't white
+space or part of a comment.
+*/
+func skipSpace(data []byte, index int) int {
+	for index < len(data) {
+		switch {
+		case data[index] == ' ' || data[index] == '\t' || data[index] == '\n' || data[index] == '\r':
+			index++
+
+		case data[index] == '/' && index+1 < len(data) && (data[index+1] == '/' || data[index+1] == '*'):
+			end, err := skipComment(data, index)
+
+			if err != nil {
+				return len(data)
+			}
+
+			index = end + 1
+
+		default:
+			return index
+		}
+	}
+
+	return index
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || (c >= '0' && c <= '9')
+}
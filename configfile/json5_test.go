@@ -0,0 +1,57 @@
+package configfile
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON5ToJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		err   bool
+	}{
+		{name: "plain JSON", input: `{"a": 1}`, want: `{"a": 1}`},
+		{name: "line comment", input: "{\"a\": 1 // one\n}", want: "{\"a\": 1 \n}"},
+		{name: "block comment", input: `{/* first */"a": 1}`, want: `{"a": 1}`},
+		{name: "comment in string", input: `{"a": "http://host"}`, want: `{"a": "http://host"}`},
+		{name: "trailing comma in object", input: `{"a": 1,}`, want: `{"a": 1}`},
+		{name: "trailing comma in array", input: `[1, 2, ]`, want: `[1, 2 ]`},
+		{name: "unquoted key", input: `{a: 1}`, want: `{"a": 1}`},
+		{name: "literals", input: `{a: true, b: null}`, want: `{"a": true, "b": null}`},
+		{name: "single quoted", input: `{'a': 'b'}`, want: `{"a": "b"}`},
+		{name: "double quote in single quoted", input: `{a: 'say "hi"'}`, want: `{"a": "say \"hi\""}`},
+		{name: "escaped single quote in single quoted", input: `{a: 'it\'s'}`, want: `{"a": "it's"}`},
+		{name: "escaped single quote in double quoted", input: `{a: "it\'s"}`, want: `{"a": "it's"}`},
+		{name: "other escapes", input: `{a: "line\nbreak \"q\""}`, want: `{"a": "line\nbreak \"q\""}`},
+		{name: "unterminated string", input: `{a: "open}`, err: true},
+		{name: "unterminated comment", input: `{a: 1 /* open}`, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := json5ToJSON([]byte(test.input))
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != test.want {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+
+			if !json.Valid(got) {
+				t.Errorf("%s is not valid JSON", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package configfile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+/*
+ReadJsonnet evaluates a Jsonnet file using the jsonnet command line tool
+(https://jsonnet.org), which must be in your PATH. Imports are resolved
+relative to the file. External variables are passed as strings, and are
+read in the file with std.extVar. For example:
+
+	local env = std.extVar('env');
+	{
+	  host: if env == 'production' then 'db.example.com' else 'localhost',
+	}
+*/
+func ReadJsonnet(fileName string, extVars map[string]string) (Values, error) {
+	return ReadJsonnetContext(context.Background(), fileName, extVars)
+}
+
+/*
+ReadJsonnetContext evaluates a Jsonnet file like ReadJsonnet, killing
+the jsonnet tool if the context is cancelled first.
+*/
+func ReadJsonnetContext(ctx context.Context, fileName string, extVars map[string]string) (Values, error) {
+	var (
+		err      error
+		output   []byte
+		document interface{}
+	)
+
+	names := make([]string, 0, len(extVars))
+
+	for name := range extVars {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	args := make([]string, 0, len(names)*2+1)
+
+	for _, name := range names {
+		args = append(args, "--ext-str", name+"="+extVars[name])
+	}
+
+	args = append(args, fileName)
+
+	if output, err = RunToolContext(ctx, "jsonnet", args...); err != nil {
+		return Values{}, fmt.Errorf("error evaluating Jsonnet: %w", err)
+	}
+
+	if document, err = decodeJSON(output); err != nil {
+		return Values{}, err
+	}
+
+	return New(FormatJsonnet, document), nil
+}
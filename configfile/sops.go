@@ -0,0 +1,43 @@
+package configfile
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+IsSOPS returns true if the values come from a file encrypted with SOPS
+(https://github.com/getsops/sops). SOPS adds its metadata, including a
+MAC of the contents, under a top level "sops" key.
+*/
+func (v Values) IsSOPS() bool {
+	_, ok := v.values["sops-mac"]
+	return ok
+}
+
+/*
+ReadSOPS decrypts a YAML or JSON file encrypted with SOPS, using the
+sops command line tool, which must be in your PATH. The sops tool finds
+the keys itself, whether age, PGP, or a cloud KMS, so encrypted config
+can live safely in git. The plaintext is only held in memory.
+*/
+func ReadSOPS(fileName string) (Values, error) {
+	return ReadSOPSContext(context.Background(), fileName)
+}
+
+/*
+ReadSOPSContext decrypts a file encrypted with SOPS like ReadSOPS,
+killing the sops tool if the context is cancelled first.
+*/
+func ReadSOPSContext(ctx context.Context, fileName string) (Values, error) {
+	var (
+		err    error
+		output []byte
+	)
+
+	if output, err = RunToolContext(ctx, "sops", "--decrypt", fileName); err != nil {
+		return Values{}, fmt.Errorf("error decrypting %s: %w", fileName, err)
+	}
+
+	return Parse(fileName, output)
+}
@@ -0,0 +1,60 @@
+package configfile
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+)
+
+/*
+ParseTFVars decodes a Terraform variable definitions (.tfvars) file, so
+values already declared for infrastructure can be reused by the
+application. Terraform names variables with underscores, so underscores
+in keys are treated as dashes. This means
+
+	instance_type = "t3.small"
+
+has a value for both "instance_type" and "instance-type".
+*/
+func ParseTFVars(data []byte) (Values, error) {
+	var (
+		err      error
+		document interface{}
+	)
+
+	if err = hcl.Unmarshal(data, &document); err != nil {
+		return Values{}, fmt.Errorf("error parsing tfvars: %w", err)
+	}
+
+	result := New(FormatTFVars, document)
+	values := make(map[string]interface{}, len(result.values))
+
+	for key, value := range result.values {
+		values[tfvarsKey(key)] = value
+	}
+
+	result.values = values
+	return result, nil
+}
+
+/*
+ReadTFVars reads and decodes a Terraform variable definitions file.
+*/
+func ReadTFVars(fileName string) (Values, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	if data, err = os.ReadFile(fileName); err != nil {
+		return Values{}, err
+	}
+
+	return ParseTFVars(data)
+}
+
+func tfvarsKey(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
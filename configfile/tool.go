@@ -0,0 +1,51 @@
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/*
+RunTool runs an external command and returns what it writes to stdout.
+Formats such as CUE are evaluated by their own command line tools, rather
+than pulling their whole toolchain into every program that uses this
+package. The same goes for services with heavy SDKs, such as S3. The
+error includes anything the command wrote to stderr.
+*/
+func RunTool(name string, args ...string) ([]byte, error) {
+	return RunToolContext(context.Background(), name, args...)
+}
+
+/*
+RunToolContext runs an external command like RunTool, killing it if the
+context is cancelled or its deadline passes first.
+*/
+func RunToolContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var (
+		err    error
+		stdout bytes.Buffer
+		stderr bytes.Buffer
+	)
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("the '%s' command was not found in your PATH", name)
+		}
+
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %s", name, message)
+		}
+
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+ParseXML decodes an XML document. The children of the root element are
+the top level keys. Elements and attributes are both matched by name,
+so
+
+	<config>
+	  <database host="localhost">
+	    <port>5432</port>
+	  </database>
+	  <upstream><host>a.example.com</host></upstream>
+	  <upstream><host>b.example.com</host></upstream>
+	</config>
+
+has values for "database-host", "database-port", "upstream-0-host",
+and "upstream-1-host". Repeated elements become a list. The text of an
+element that also has attributes or children is found under "value".
+*/
+func ParseXML(data []byte) (Values, error) {
+	var (
+		err      error
+		token    xml.Token
+		document interface{}
+	)
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		if token, err = decoder.Token(); err != nil {
+			if err == io.EOF {
+				return New(FormatXML, document), nil
+			}
+
+			return Values{}, fmt.Errorf("error parsing XML: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			if document, err = decodeXMLElement(decoder, start); err != nil {
+				return Values{}, fmt.Errorf("error parsing XML: %w", err)
+			}
+
+			return New(FormatXML, document), nil
+		}
+	}
+}
+
+/*
+ReadXML reads and decodes an XML file.
+*/
+func ReadXML(fileName string) (Values, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	if data, err = os.ReadFile(fileName); err != nil {
+		return Values{}, err
+	}
+
+	return ParseXML(data)
+}
+
+/*
+decodeXMLElement decodes an element into a string, when it holds only
+text, or a map of its attributes and children.
+*/
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var (
+		err   error
+		token xml.Token
+		child interface{}
+		text  strings.Builder
+	)
+
+	result := make(map[string]interface{})
+
+	for _, attribute := range start.Attr {
+		addXMLValue(result, attribute.Name.Local, attribute.Value)
+	}
+
+	for {
+		if token, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		switch typed := token.(type) {
+		case xml.StartElement:
+			if child, err = decodeXMLElement(decoder, typed); err != nil {
+				return nil, err
+			}
+
+			addXMLValue(result, typed.Name.Local, child)
+
+		case xml.CharData:
+			text.Write(typed)
+
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+
+			if len(result) == 0 {
+				return value, nil
+			}
+
+			if value != "" {
+				addXMLValue(result, "value", value)
+			}
+
+			return result, nil
+		}
+	}
+}
+
+func addXMLValue(result map[string]interface{}, name string, value interface{}) {
+	existing, ok := result[name]
+
+	if !ok {
+		result[name] = value
+		return
+	}
+
+	if list, ok := existing.([]interface{}); ok {
+		result[name] = append(list, value)
+		return
+	}
+
+	result[name] = []interface{}{existing, value}
+}
@@ -0,0 +1,40 @@
+package configfile
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+/*
+ParseYAML decodes a YAML document.
+*/
+func ParseYAML(data []byte) (Values, error) {
+	var (
+		err      error
+		document interface{}
+	)
+
+	if err = yaml.Unmarshal(data, &document); err != nil {
+		return Values{}, fmt.Errorf("error parsing YAML: %w", err)
+	}
+
+	return New(FormatYAML, document), nil
+}
+
+/*
+ReadYAML reads and decodes a YAML file.
+*/
+func ReadYAML(fileName string) (Values, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	if data, err = os.ReadFile(fileName); err != nil {
+		return Values{}, err
+	}
+
+	return ParseYAML(data)
+}
@@ -0,0 +1,171 @@
+package configinator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/app-nerds/configinator/configfile"
+	"github.com/app-nerds/configinator/env"
+)
+
+/*
+DefaultConfigFlag is the name of the flag used to choose a config file
+at runtime. See WithConfigFlag.
+*/
+const DefaultConfigFlag string = "config"
+
+/*
+StdinConfig is the config file name that reads the config document
+from stdin, as in "-config -".
+*/
+const StdinConfig string = "-"
+
+/*
+readConfigFile reads the config files named by the config flag, which
+must exist. Without the flag, the files from WithConfigFile that exist
+are read. Otherwise, with WithSearchPaths, the first file found named in
+configFileNames is read, looking in each search dir in turn. No config
+file is read unless one of these asks for it, so a config.json that
+belongs to another tool is left alone. Several files are merged in
+order, with later files overriding earlier ones, once each is keyed by
+flag name. The context kills any tool that evaluates or decrypts a
+file.
+*/
+func readConfigFile(ctx context.Context, o *options, keys fileKeys) (configfile.Values, error) {
+	if fileNames := configFlagValues(o.args, o.configFlag); len(fileNames) > 0 {
+		for _, fileName := range fileNames {
+			if fileName != StdinConfig && !env.FileExists(fileName) {
+				return configfile.Values{}, fmt.Errorf("config file '%s' does not exist", fileName)
+			}
+		}
+
+		return readConfigFiles(ctx, fileNames, o.stdinFormat, keys)
+	}
+
+	if o.configFiles != nil {
+		existing := make([]string, 0, len(o.configFiles))
+
+		for _, fileName := range o.configFiles {
+			if fileName == StdinConfig || env.FileExists(fileName) {
+				existing = append(existing, fileName)
+			}
+		}
+
+		return readConfigFiles(ctx, existing, o.stdinFormat, keys)
+	}
+
+	for _, dir := range o.searchDirs {
+		for _, fileName := range configFileNames {
+			if path := filepath.Join(dir, fileName); env.FileExists(path) {
+				return readConfigFiles(ctx, []string{path}, o.stdinFormat, keys)
+			}
+		}
+	}
+
+	return configfile.Values{}, nil
+}
+
+func readConfigFiles(ctx context.Context, fileNames []string, stdinFormat string, keys fileKeys) (configfile.Values, error) {
+	var (
+		err    error
+		values configfile.Values
+	)
+
+	all := make([]configfile.Values, 0, len(fileNames))
+
+	for _, fileName := range fileNames {
+		if fileName == StdinConfig {
+			values, err = readStdinConfig(os.Stdin, stdinFormat)
+		} else {
+			values, err = configfile.ReadContext(ctx, fileName)
+		}
+
+		if err != nil {
+			return configfile.Values{}, err
+		}
+
+		if values, err = keys.rename(values); err != nil {
+			return configfile.Values{}, err
+		}
+
+		all = append(all, values)
+	}
+
+	return configfile.Merge(all...), nil
+}
+
+/*
+configFlagValues finds the values of the config flag in the command line
+arguments. The config file has to be read before the struct is set up,
+and so before the flags are parsed.
+*/
+func configFlagValues(args []string, name string) []string {
+	var (
+		result []string
+	)
+
+	if name == "" {
+		return result
+	}
+
+	for index, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		trimmed := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+
+		if trimmed == arg {
+			continue
+		}
+
+		if trimmed == name && index+1 < len(args) {
+			result = append(result, args[index+1])
+			continue
+		}
+
+		if value, ok := strings.CutPrefix(trimmed, name+"="); ok {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+/*
+addConfigFlag defines the config flag, so it is shown in help and
+accepted when flags are parsed. A field already using the name keeps it.
+*/
+func addConfigFlag(flags *flag.FlagSet, name string) {
+	if name == "" || flags.Parsed() || flags.Lookup(name) != nil {
+		return
+	}
+
+	flags.String(name, "", "Path to a config file. Repeat to overlay several files")
+}
+
+/*
+readStdinConfig reads a config document from stdin. Without a format,
+it is guessed from the document.
+*/
+func readStdinConfig(r io.Reader, format string) (configfile.Values, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	if data, err = io.ReadAll(r); err != nil {
+		return configfile.Values{}, fmt.Errorf("error reading config from stdin: %w", err)
+	}
+
+	if format == "" {
+		format = configfile.DetectFormat(data)
+	}
+
+	return configfile.ParseFormat(format, data)
+}
@@ -0,0 +1,37 @@
+package configinator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigFlagValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		flag string
+		want []string
+	}{
+		{name: "no flag", args: []string{"-host", "h"}, flag: "config", want: nil},
+		{name: "separate value", args: []string{"-config", "a.yaml"}, flag: "config", want: []string{"a.yaml"}},
+		{name: "two dashes", args: []string{"--config", "a.yaml"}, flag: "config", want: []string{"a.yaml"}},
+		{name: "equals", args: []string{"-config=a.yaml"}, flag: "config", want: []string{"a.yaml"}},
+		{name: "two dashes and equals", args: []string{"--config=a.yaml"}, flag: "config", want: []string{"a.yaml"}},
+		{name: "repeated", args: []string{"-config", "a.yaml", "-v", "--config=b.json"}, flag: "config", want: []string{"a.yaml", "b.json"}},
+		{name: "stdin", args: []string{"-config", "-"}, flag: "config", want: []string{"-"}},
+		{name: "missing value", args: []string{"-config"}, flag: "config", want: nil},
+		{name: "after double dash", args: []string{"--", "-config", "a.yaml"}, flag: "config", want: nil},
+		{name: "similar name", args: []string{"-configdir", "x", "-config-file=y"}, flag: "config", want: nil},
+		{name: "argument", args: []string{"config", "a.yaml"}, flag: "config", want: nil},
+		{name: "renamed flag", args: []string{"-settings", "a.yaml", "-config", "b.yaml"}, flag: "settings", want: []string{"a.yaml"}},
+		{name: "turned off", args: []string{"-config", "a.yaml"}, flag: "", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := configFlagValues(test.args, test.flag); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
@@ -1,14 +1,27 @@
 package configinator
 
 import (
-	"flag"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/app-nerds/configinator/container"
 	"github.com/app-nerds/configinator/env"
 )
 
+var (
+	/*
+	 * Config files looked for by WithSearchPaths and WithDefaults. Other
+	 * files, such as CUE and Jsonnet files, which run the cue and jsonnet
+	 * tools, are only read when they are named
+	 */
+	configFileNames = []string{"config.yaml", "config.yml", "config.json", "config.json5", "config.hcl", "config.tfvars", "config.xml"}
+)
+
 /*
 Behold initializes a provided struct with values from defaults,
 environment, .env file, and flags. It does this by adding tags to your
@@ -22,125 +35,502 @@ The above example will accept a command line flag of "host",
 or an environment variable named "HOST". If none of the above
 are provided then the value from 'default' is used.
 
-If an .env file is found that will be read and used.
+If an .env file is found that will be read and used. Config files are
+only read when asked for, by the -config flag, WithConfigFile, or
+WithSearchPaths. Options change this behavior. For example, WithEnvFile
+reads other env files, and WithoutEnvFile reads none.
+*/
+func Behold(config interface{}, opts ...Option) {
+	BeholdContext(context.Background(), config, opts...)
+}
+
+/*
+BeholdE initializes a provided struct like Behold, returning an error
+rather than panicking when something goes wrong, such as an unreadable
+.env file, a default that doesn't convert to its field's type, or a
+field with a flag tag that isn't exported. Errors for a field are a
+*FieldError.
 */
-func Behold(config interface{}) {
+func BeholdE(config interface{}, opts ...Option) error {
+	return BeholdContextE(context.Background(), config, opts...)
+}
+
+/*
+Load allocates a config struct of type T, initializes it like BeholdE,
+and returns it. Unlike Behold, the type is checked when compiling, so
+there's no pointer to get wrong.
+
+	config, err := configinator.Load[Config]()
+*/
+func Load[T any](opts ...Option) (*T, error) {
+	return LoadContext[T](context.Background(), opts...)
+}
+
+/*
+LoadContext allocates and initializes a config struct like Load,
+fetching remote sources with the context like BeholdContext.
+*/
+func LoadContext[T any](ctx context.Context, opts ...Option) (*T, error) {
+	result := new(T)
+
+	if err := BeholdContextE(ctx, result, opts...); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+/*
+BeholdContext initializes a provided struct like Behold. Remote sources,
+such as HTTP documents and custom sources, are fetched with the context,
+so loading stops when it is cancelled or its deadline passes. Watched
+sources stop watching when the context is cancelled.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	configinator.BeholdContext(ctx, &config, configinator.WithHTTP(source))
+*/
+func BeholdContext(ctx context.Context, config interface{}, opts ...Option) {
+	if err := BeholdContextE(ctx, config, opts...); err != nil {
+		panic(err)
+	}
+}
+
+/*
+BeholdContextE initializes a provided struct like BeholdContext,
+returning an error rather than panicking, like BeholdE.
+*/
+func BeholdContextE(ctx context.Context, config interface{}, opts ...Option) error {
 	var (
-		err        error
-		index      int
-		containers []*container.Container
+		err   error
+		files container.Files
 	)
 
-	envFile := make(map[string]string)
+	configValue := reflect.ValueOf(config)
+
+	if configValue.Kind() != reflect.Ptr || configValue.IsNil() || configValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a pointer to a struct, not %T", config)
+	}
+
+	o := newOptions(opts)
+
+	if files, err = readFiles(ctx, o, configValue.Elem()); err != nil {
+		return err
+	}
+
+	if err = load(ctx, o, files, configValue.Elem()); err != nil {
+		return err
+	}
+
+	watchSources(ctx, o)
+	return nil
+}
+
+/*
+readFiles reads the env files, secret files, config files, and remote
+documents the options ask for. Config documents are keyed by the flag
+names of the config struct's fields as they are read.
+*/
+func readFiles(ctx context.Context, o *options, config reflect.Value) (container.Files, error) {
+	var (
+		err error
+	)
+
+	files := container.Files{
+		Env:        make(map[string]string),
+		EnvPrefix:  o.envPrefix,
+		EnvNaming:  o.envNaming,
+		FlagNaming: o.flagNaming,
+		TagPrefix:  o.tagPrefix,
+	}
+
+	keys := fileKeys{
+		config: config,
+		files:  files,
+		prefix: o.prefix,
+	}
+
+	/*
+	 * If we have environment files, load them
+	 */
+	if files.Env, err = readEnvFiles(ctx, o.envFileNames(), o.ageIdentity); err != nil {
+		return files, err
+	}
+
+	if err = readEnvDirs(o.envDirs, files.Env); err != nil {
+		return files, err
+	}
+
+	/*
+	 * Read secret files, such as Docker secrets and systemd credentials
+	 */
+	files.SecretDirs = secretDirs(o.secretDirNames())
 
 	/*
-	 * If we have an environment file, load it
+	 * If we have a config file, load it
 	 */
-	if env.FileExists(".env") {
-		if envFile, err = env.ReadFile(".env"); err != nil {
-			panic(err)
+	if files.Config, err = readConfigFile(ctx, o, keys); err != nil {
+		return files, err
+	}
+
+	/*
+	 * Embedded defaults sit beneath the files on disk
+	 */
+	if o.defaults != nil {
+		if err = applyDefaults(o.defaults, &files, keys); err != nil {
+			return files, err
 		}
 	}
 
 	/*
-	 * Read the type info for this struct
+	 * Remote documents are fetched concurrently and placed over the files on disk
 	 */
-	t := reflect.TypeOf(config).Elem()
-	containers = make([]*container.Container, t.NumField())
+	if err = applyRemoteSources(ctx, o.remoteSources(), o.fetchWorkers, &files, keys); err != nil {
+		return files, err
+	}
+
+	return files, nil
+}
+
+/*
+load sets up the fields of a config struct, parses its flags, and sets
+each field from the sources in order of precedence. When a subcommand
+is named on the command line, its struct is loaded the same way with
+the arguments that follow it.
+*/
+func load(ctx context.Context, o *options, files container.Files, config reflect.Value) error {
+	var (
+		err         error
+		containers  []*container.Container
+		layers      []layer
+		showVersion *bool
+	)
+
+	files.Flags = o.flags
+	files.Args = o.args
 
 	/*
 	 * First setup each field of the config struct. These are stored in "containers".
 	 * Each container know the field type, value, env name, flag name, and adds
-	 * to the provided flag set.
+	 * to the provided flag set. Nested structs are walked as well.
 	 */
-	for index = 0; index < t.NumField(); index++ {
-		containers[index], _ = container.New(config, index, envFile)
+	if containers, err = setupContainers(config, o.prefix, files); err != nil {
+		return err
+	}
+
+	addConfigFlag(o.flags, o.configFlag)
+	setUsage(o.flags, containers, commandFields(config, files), o.gnuFlags, o.noColor)
+
+	if o.version != nil {
+		showVersion = addVersionFlag(o.flags)
 	}
 
 	/*
 	 * Parse flags
 	 */
-	if len(os.Args) > 1 && !flag.Parsed() {
-		flag.Parse()
+	if len(o.args) > 0 && !o.flags.Parsed() {
+		args := o.args
+
+		if o.gnuFlags {
+			if args, err = gnuArgs(o.flags, args, len(commandFields(config, files)) > 0); err != nil {
+				return err
+			}
+		}
+
+		if err = o.flags.Parse(args); err != nil {
+			return err
+		}
+	}
+
+	if showVersion != nil && *showVersion {
+		return printVersion(os.Stdout, *o.version, o.flags.ErrorHandling())
 	}
 
 	/*
-	 * Set the values in the config struct. They already have default value set.
-	 * So first we check to see if there is an environment variable. Then we
-	 * check to see if there is an environment file value. Finally we check for a
-	 * flag value.
+	 * Set the values in the config struct. They already have default value set,
+	 * which an embedded .env file can replace. Then we check to see if there is
+	 * a config file value, then a secret file, then a file named by a _FILE
+	 * env variable. Then we check the sources: the environment, the .env file,
+	 * any added with WithSource, and finally the flags. A value that can't be
+	 * converted to the field's type, or doesn't fit in it, is an error.
 	 */
-	for index = 0; index < t.NumField(); index++ {
-		c := containers[index]
+	if layers, err = resolveLayers(ctx, o, files); err != nil {
+		return err
+	}
 
-		if c.IsBool() {
-			if value, ok := c.EnvBool(); ok {
-				c.SetConfigBool(value)
+	for _, c := range containers {
+		if c.IsRest() {
+			continue
+		}
+
+		for _, l := range layers {
+			if !c.AllowsSource(l.name) {
+				continue
 			}
 
-			if value, ok := c.EnvFileBool(); ok {
-				c.SetConfigBool(value)
+			value, ok, err := l.lookup(c)
+
+			if err != nil {
+				return newFieldError(c, l.name, err)
 			}
 
-			if value, ok := c.FlagBool(); ok {
-				c.SetConfigBool(value)
+			if !ok {
+				continue
+			}
+
+			if o.fileValues {
+				if value, err = readFileValue(value); err != nil {
+					return newFieldError(c, l.name, err)
+				}
 			}
-		}
 
-		if c.IsFloat() {
-			if value, ok := c.EnvFloat(); ok {
-				c.SetConfigFloat(value)
+			if value, err = decryptValue(ctx, value, o.decrypter); err != nil {
+				return newFieldError(c, l.name, err)
 			}
 
-			if value, ok := c.EnvFileFloat(); ok {
-				c.SetConfigFloat(value)
+			if err = c.SetConfigValue(value, l.name); err != nil {
+				return newFieldError(c, l.name, err)
 			}
+		}
+	}
+
+	warnDeprecated(o.warnings, containers)
+
+	if err = validateRequired(containers); err != nil {
+		return err
+	}
 
-			if value, ok := c.FlagFloat(); ok {
-				c.SetConfigFloat(value)
+	if err = validateFields(ctx, containers); err != nil {
+		return err
+	}
+
+	/*
+	 * Finally, any env values not bound to a field are collected
+	 * into rest fields
+	 */
+	bound := make(map[string]bool)
+
+	for _, c := range containers {
+		if !c.IsRest() {
+			for _, name := range c.EnvNames() {
+				bound[name] = true
 			}
 		}
+	}
+
+	for _, c := range containers {
+		c.SetRestValues(bound)
+	}
+
+	if o.validator != nil {
+		if err = o.validator.Struct(config.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	/*
+	 * Let the config struct, and the structs in it such as TLSFiles,
+	 * check their values against each other
+	 */
+	if err = validateStructs(config, files); err != nil {
+		return err
+	}
+
+	return loadCommand(ctx, o, files, config)
+}
 
-		if c.IsInt() {
-			if value, ok := c.EnvInt(); ok {
-				c.SetConfigInt(value)
+/*
+readEnvFiles reads the env files that exist, in order. Values in later
+files override earlier ones. Each file is followed by its encrypted
+versions, with .age or .gpg added to the name, so .env.age is read
+after .env. Files encrypted with SOPS are decrypted too. When the
+DOTENV_KEY variable is set, a .env.vault file is read in place of the
+env file and its encrypted versions.
+*/
+func readEnvFiles(ctx context.Context, fileNames []string, ageIdentity string) (map[string]string, error) {
+	var (
+		err    error
+		values map[string]string
+	)
+
+	result := make(map[string]string)
+
+	dotenvKey := os.Getenv(DotenvKeyVariable)
+
+	for _, fileName := range fileNames {
+		candidates := []string{fileName, fileName + ageExtension, fileName + pgpExtension}
+
+		if dotenvKey != "" && env.FileExists(fileName+vaultExtension) {
+			candidates = []string{fileName + vaultExtension}
+		}
+
+		for _, candidate := range candidates {
+			if !env.FileExists(candidate) {
+				continue
 			}
 
-			if value, ok := c.EnvFileInt(); ok {
-				c.SetConfigInt(value)
+			if values, err = readEnvFile(ctx, candidate, ageIdentity, dotenvKey); err != nil {
+				return result, err
 			}
 
-			if value, ok := c.FlagInt(); ok {
-				c.SetConfigInt(value)
+			for key, value := range values {
+				result[key] = value
 			}
 		}
+	}
+
+	return result, nil
+}
+
+/*
+readEnvDirs reads envdir style directories into the env file values.
+*/
+func readEnvDirs(dirs []string, result map[string]string) error {
+	var (
+		err    error
+		values map[string]string
+	)
+
+	for _, dir := range dirs {
+		if values, err = env.ReadDir(dir); err != nil {
+			return err
+		}
 
-		if c.IsString() {
-			if value, ok := c.EnvString(); ok {
-				c.SetConfigString(value)
+		for key, value := range values {
+			result[key] = value
+		}
+	}
+
+	return nil
+}
+
+/*
+secretDirs returns the secret directories that exist, with the ..data
+directory of a Kubernetes mount in place of the mount. Secret files
+are only read when a field looks for one.
+*/
+func secretDirs(dirs []string) []string {
+	result := make([]string, 0, len(dirs))
+
+	for _, dir := range dirs {
+		if env.FileExists(dir) {
+			result = append(result, env.DataDir(dir))
+		}
+	}
+
+	return result
+}
+
+/*
+setupContainers creates a container for each field of the provided struct
+value. Fields that are private or have no flag name are skipped. Struct
+fields without a flag name are walked recursively, with their prefix tag
+added to the prefix applied to the flag and env names of their fields.
+Embedded structs are walked the same way, so their fields are promoted
+as if they were declared on the parent. Nil embedded pointers are allocated.
+*/
+func setupContainers(config reflect.Value, prefix string, files container.Files) ([]*container.Container, error) {
+	var (
+		err        error
+		c          *container.Container
+		nested     []*container.Container
+		containers []*container.Container
+	)
+
+	t := config.Type()
+
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		fieldValue := config.Field(index)
+
+		/*
+		 * A flag name of "-" leaves the field alone, such as one set at runtime
+		 */
+		if flagName, _ := files.LookupTag(field, container.TagFlagName); flagName == container.IgnoreFlag {
+			continue
+		}
+
+		/*
+		 * Subcommands are loaded once flags are parsed, if they are given
+		 */
+		if _, isCommand := files.LookupTag(field, container.TagCommand); isCommand {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			if !fieldValue.CanSet() {
+				continue
 			}
 
-			if value, ok := c.EnvFileString(); ok {
-				c.SetConfigString(value)
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(field.Type.Elem()))
 			}
 
-			if value, ok := c.FlagString(); ok {
-				c.SetConfigString(value)
+			fieldValue = fieldValue.Elem()
+		}
+
+		if isNestedStruct(field, files) && (fieldValue.CanSet() || field.Anonymous) {
+			if nested, err = setupContainers(fieldValue, joinPrefix(prefix, files.Tag(field, container.TagPrefix)), files); err != nil {
+				return containers, err
 			}
+
+			containers = append(containers, nested...)
+			continue
 		}
 
-		if c.IsTime() {
-			if value, ok := c.EnvTime(); ok {
-				c.SetConfigTime(value)
+		if isStructSlice(field, files) && fieldValue.CanSet() {
+			if nested, err = setupStructSlice(fieldValue, joinPrefix(prefix, structSlicePrefix(field, files)), files); err != nil {
+				return containers, err
 			}
 
-			if value, ok := c.EnvFileTime(); ok {
-				c.SetConfigTime(value)
+			containers = append(containers, nested...)
+			continue
+		}
+
+		if c, err = container.New(config, index, files, prefix); err != nil {
+			flagName, hasFlag := files.LookupTag(field, container.TagFlagName)
+
+			/*
+			 * Untagged and private fields are skipped, unless a private
+			 * field is tagged, which is a mistake worth reporting
+			 */
+			if errors.Is(err, container.ErrNoFlagName) || (errors.Is(err, container.ErrCantSet) && !hasFlag) {
+				continue
 			}
 
-			if value, ok := c.FlagTime(); ok {
-				c.SetConfigTime(value)
+			if errors.Is(err, container.ErrCantSet) {
+				return containers, &FieldError{Field: field.Name, Flag: joinPrefix(prefix, strings.Split(flagName, ",")[0]), Err: err}
 			}
+
+			return containers, newFieldError(c, "", err)
 		}
+
+		containers = append(containers, c)
+	}
+
+	return containers, nil
+}
+
+func isNestedStruct(field reflect.StructField, files container.Files) bool {
+	_, hasFlag := files.LookupTag(field, container.TagFlagName)
+	t := field.Type
+
+	if field.Anonymous && t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
+
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) && !hasFlag
+}
+
+func joinPrefix(prefix, child string) string {
+	if prefix == "" {
+		return child
+	}
+
+	if child == "" {
+		return prefix
+	}
+
+	return prefix + "-" + child
 }
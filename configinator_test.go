@@ -0,0 +1,241 @@
+package configinator
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRepeatedSliceFlags(t *testing.T) {
+	type config struct {
+		Tags  []string `flag:"tag" default:"x,y"`
+		Ports []int    `flag:"port"`
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want config
+	}{
+		{name: "default", want: config{Tags: []string{"x", "y"}}},
+		{name: "repeated", args: []string{"-tag", "a", "-tag", "b"}, want: config{Tags: []string{"a", "b"}}},
+		{name: "repeated and separated", args: []string{"-tag", "a,b", "-tag", "c"}, want: config{Tags: []string{"a", "b", "c"}}},
+		{name: "repeated ints", args: []string{"-port", "80", "-port=443"}, want: config{Tags: []string{"x", "y"}, Ports: []int{80, 443}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			if err := beholdArgs(&got, test.args...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestEmptySlices(t *testing.T) {
+	type config struct {
+		Ports []int    `flag:"port" env:"PORTS" json:"ports" default:"80"`
+		Tags  []string `flag:"tag" env:"TAGS" json:"tags" default:"x"`
+	}
+
+	fileName := filepath.Join(t.TempDir(), "config.json")
+
+	if err := os.WriteFile(fileName, []byte(`{"ports": [], "tags": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		options []Option
+	}{
+		{name: "empty env", env: map[string]string{"PORTS": "", "TAGS": " "}},
+		{name: "empty file lists", options: []Option{WithConfigFile(fileName)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			if err := BeholdE(&got, append([]Option{WithFlagSet(flags), WithArgs(nil)}, test.options...)...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := (config{Ports: []int{}, Tags: []string{}}); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %#v, got %#v", want, got)
+			}
+		})
+	}
+}
+
+func TestOnlyTag(t *testing.T) {
+	type config struct {
+		Token string `flag:"token" env:"TOKEN" json:"token" only:"env"`
+		Debug bool   `flag:"debug" env:"DEBUG" json:"debug" only:"flag"`
+	}
+
+	fileName := filepath.Join(t.TempDir(), "config.json")
+
+	if err := os.WriteFile(fileName, []byte(`{"token": "from-file", "debug": true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		options []Option
+		want    config
+		err     bool
+	}{
+		{name: "env", env: map[string]string{"TOKEN": "from-env", "DEBUG": "true"}, want: config{Token: "from-env"}},
+		{name: "flag", args: []string{"-debug"}, want: config{Debug: true}},
+		{name: "no flag for only env", args: []string{"-token", "x"}, err: true},
+		{name: "config file", options: []Option{WithConfigFile(fileName)}, want: config{Token: "from-file", Debug: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			err := BeholdE(&got, append([]Option{WithFlagSet(flags), WithArgs(test.args)}, test.options...)...)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != test.want {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFileRefValues(t *testing.T) {
+	type config struct {
+		Password string `flag:"password" env:"DB_PASSWORD"`
+	}
+
+	dir := t.TempDir()
+
+	write := func(name, value string) string {
+		path := filepath.Join(dir, name)
+
+		if err := os.WriteFile(path, []byte(value), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	fromEnv := write("env-password", "from-env\n")
+	fromFile := write("file-password", "from-env-file\n")
+	envFile := write(".env", "DB_PASSWORD_FILE="+fromFile+"\n")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{name: "env file", want: "from-env-file"},
+		{name: "environment before env file", env: map[string]string{"DB_PASSWORD_FILE": fromEnv}, want: "from-env"},
+		{name: "value set directly", env: map[string]string{"DB_PASSWORD_FILE": fromEnv, "DB_PASSWORD": "direct"}, want: "direct"},
+		{name: "flag", env: map[string]string{"DB_PASSWORD_FILE": fromEnv}, args: []string{"-password", "flag"}, want: "flag"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			if err := BeholdE(&got, WithFlagSet(flags), WithArgs(test.args), WithEnvFile(envFile)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Password != test.want {
+				t.Errorf("expected %q, got %q", test.want, got.Password)
+			}
+		})
+	}
+}
+
+func TestWithEnvDir(t *testing.T) {
+	type config struct {
+		Host string `flag:"host" env:"HOST"`
+		Port int    `flag:"port" env:"PORT"`
+	}
+
+	var (
+		got config
+	)
+
+	base := t.TempDir()
+	overlay := t.TempDir()
+
+	for path, value := range map[string]string{
+		filepath.Join(base, "HOST"):    "base",
+		filepath.Join(base, "PORT"):    "80",
+		filepath.Join(overlay, "HOST"): "overlay\n",
+	} {
+		if err := os.WriteFile(path, []byte(value), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	if err := BeholdE(&got, WithFlagSet(flags), WithArgs(nil), WithEnvDir(base), WithEnvDir(overlay)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := (config{Host: "overlay", Port: 80}); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
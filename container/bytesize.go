@@ -0,0 +1,54 @@
+package container
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	byteSizeRegex = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*([a-zA-Z]+)\s*$`)
+
+	byteSizeUnits = map[string]float64{
+		"b":   1,
+		"kb":  1e3,
+		"mb":  1e6,
+		"gb":  1e9,
+		"tb":  1e12,
+		"pb":  1e15,
+		"kib": 1 << 10,
+		"mib": 1 << 20,
+		"gib": 1 << 30,
+		"tib": 1 << 40,
+		"pib": 1 << 50,
+	}
+)
+
+/*
+parseByteSize parses a human readable size, such as "10MB", "512KiB",
+or "1.5GiB", into a number of bytes. Units are case insensitive. KB, MB,
+etc.. are powers of 1000, while KiB, MiB, etc.. are powers of 1024. The
+second return value is false if the value isn't a byte size.
+*/
+func parseByteSize(value string) (float64, bool) {
+	matches := byteSizeRegex.FindStringSubmatch(value)
+
+	if matches == nil {
+		return 0, false
+	}
+
+	multiplier, ok := byteSizeUnits[strings.ToLower(matches[2])]
+
+	if !ok {
+		return 0, false
+	}
+
+	number, err := strconv.ParseFloat(matches[1], 64)
+
+	if err != nil {
+		return 0, false
+	}
+
+	return math.Floor(number * multiplier), true
+}
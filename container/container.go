@@ -1,13 +1,21 @@
 package container
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"os"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/app-nerds/configinator/configfile"
+	"github.com/app-nerds/configinator/env"
 )
 
 // Supported struct tags
@@ -16,21 +24,180 @@ const (
 	TagEnvName      string = "env"
 	TagDefaultValue string = "default"
 	TagDescription  string = "description"
+	TagSeparator    string = "separator"
+	TagPrefix       string = "prefix"
+	TagLayout       string = "layout"
+	TagEncoding     string = "encoding"
+	TagRest         string = "rest"
+	TagYAML         string = "yaml"
+	TagJSON         string = "json"
+	TagHCL          string = "hcl"
+	TagCUE          string = "cue"
+	TagJsonnet      string = "jsonnet"
+	TagTFVars       string = "tfvars"
+	TagXML          string = "xml"
+	TagSecretFile   string = "secretfile"
+	TagSources      string = "sources"
+	TagShort        string = "short"
+	TagOnly         string = "only"
+	TagDuplicates   string = "duplicates"
+	TagCount        string = "count"
+	TagCommand      string = "cmd"
+	TagGroup        string = "group"
+	TagDeprecated   string = "deprecated"
+	TagRequired     string = "required"
+	TagMin          string = "min"
+	TagMax          string = "max"
+	TagPattern      string = "pattern"
+	TagOneOf        string = "oneof"
+	TagRequiredIf   string = "required_if"
+	TagRequiredWith string = "required_with"
+	TagBind         string = "bind"
+	TagFile         string = "file"
+	TagDir          string = "dir"
+	TagMode         string = "mode"
+	TagSchemes      string = "schemes"
+	TagHostname     string = "hostname"
+)
+
+// Values of the file and dir tags
+const (
+	PathExists string = "exists"
+	PathCreate string = "create"
+)
+
+// Values of the hostname tag
+const (
+	HostnameSyntax  string = "true"
+	HostnameResolve string = "resolve"
+)
+
+// DefaultLookupTimeout is how long a hostname tag of resolve waits for DNS
+const DefaultLookupTimeout time.Duration = 5 * time.Second
+
+// DefaultDirMode is the mode directories are created with by a dir tag of create
+const DefaultDirMode os.FileMode = 0755
+
+// What a map field does with a key given more than once, set with the duplicates tag
+const (
+	DuplicatesLast  string = "last"
+	DuplicatesFirst string = "first"
+	DuplicatesError string = "error"
+)
+
+// Values of the only tag
+const (
+	OnlyEnv  string = "env"
+	OnlyFlag string = "flag"
 )
 
+// IgnoreFlag is the flag name of fields that aren't configured
+const IgnoreFlag string = "-"
+
+// FileRefSuffix is added to an env name to name a file holding the value
+const FileRefSuffix string = "_FILE"
+
+// DefaultSeparator splits values for slice fields when no separator tag is given
+const DefaultSeparator string = ","
+
+// Sources a value can come from
+const (
+	SourceDefault string = "default"
+	SourceFile    string = "file"
+	SourceSecret  string = "secret"
+	SourceEnvRef  string = "envref"
+	SourceEnv     string = "env"
+	SourceEnvFile string = "envfile"
+	SourceFlag    string = "flag"
+)
+
+/*
+Files holds the contents of the .env file, and of a structured config
+file such as YAML, JSON, HCL, CUE, Jsonnet, tfvars, or XML. DefaultEnv
+holds env values that only replace the default tag, such as those from
+an embedded .env file. SecretDirs are the directories of secret files,
+such as Docker secrets, with later directories overriding earlier ones.
+Flags is the flag set that fields add their flags to, and Args the
+command line it parses. Without them, flag.CommandLine and os.Args are
+used. EnvPrefix is put before every env name, such as "MYAPP_".
+FlagNaming and EnvNaming name the flags and env variables of fields
+without those tags. TagPrefix is put before the names of configinator's
+own tags, such as flag and env, but not the config file tags, such as
+yaml.
+*/
+type Files struct {
+	Env        map[string]string
+	DefaultEnv map[string]string
+	Config     configfile.Values
+	SecretDirs []string
+	Flags      *flag.FlagSet
+	Args       []string
+	EnvPrefix  string
+	FlagNaming func(fieldName string) string
+	EnvNaming  func(fieldName string) string
+	TagPrefix  string
+}
+
+/*
+LookupTag returns the value of one of configinator's tags on a field,
+with the tag prefix put before its name, and true if the field has it.
+*/
+func (f Files) LookupTag(field reflect.StructField, name string) (string, bool) {
+	return field.Tag.Lookup(f.TagPrefix + name)
+}
+
+/*
+Tag returns the value of one of configinator's tags on a field, like
+LookupTag, or an empty string if the field doesn't have it.
+*/
+func (f Files) Tag(field reflect.StructField, name string) string {
+	value, _ := f.LookupTag(field, name)
+	return value
+}
+
+/*
+FlagSet returns the flag set fields add their flags to.
+*/
+func (f Files) FlagSet() *flag.FlagSet {
+	if f.Flags == nil {
+		return flag.CommandLine
+	}
+
+	return f.Flags
+}
+
+/*
+Arguments returns the command line arguments, without the program name.
+*/
+func (f Files) Arguments() []string {
+	if f.Args == nil {
+		return os.Args[1:]
+	}
+
+	return f.Args
+}
+
+/*
+Optional is implemented by wrapper types, such as configinator.Optional,
+that record whether a value was provided and where it came from. Values
+are converted to OptionalType before being handed to SetOptional.
+*/
+type Optional interface {
+	OptionalType() reflect.Type
+	SetOptional(value interface{}, source string)
+}
+
 // Custom errors
 var (
 	ErrNoFlagName = fmt.Errorf("no flag name")
 	ErrCantSet    = fmt.Errorf("can't set private fields")
+	ErrOverflow   = fmt.Errorf("value overflows field type")
+	ErrInvalid    = fmt.Errorf("invalid value")
+	ErrFlagInUse  = fmt.Errorf("flag is already used")
+	ErrOutOfRange = fmt.Errorf("value out of range")
 
-	timeFormats = []string{
-		"2006-01-02",
-		"2006-01-02 15:04:05",
-		"2006-01-02T15:04:05",
-		"2006-01-02T15:04:05Z",
-		"2006-01-02T15:04:05 MST",
-		"2006-01-02T15:04:05-0700",
-	}
+	flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+	optionalType  = reflect.TypeOf((*Optional)(nil)).Elem()
 )
 
 /*
@@ -39,45 +206,100 @@ here where the logic to get values and determine if values are set as flags,
 env, etc.. is done.
 */
 type Container struct {
-	boolValue    *bool
-	config       interface{}
+	aliases      []string
+	bind         bool
 	configValue  reflect.Value
 	defaultValue string
+	deprecated   string
+	hasDefault   bool
 	description  string
-	envFile      map[string]string
+	dir          string
+	dirMode      os.FileMode
+	duplicates   string
+	files        Files
+	envAliases   []string
 	envName      string
+	excluded     map[string]bool
 	field        reflect.StructField
 	fieldName    string
 	fieldType    string
+	encoding     string
 	fieldValue   reflect.Value
+	file         string
+	fileKey      string
+	filePrefix   string
 	flagName     string
-	floatValue   *float64
-	intValue     *int
-	stringValue  *string
-	timeValue    *string
+	flagValue    *flagValue
+	group        string
+	hostname     string
+	isCount      bool
+	isOptional   bool
+	isPointer    bool
+	isRest       bool
+	layout       string
+	max          string
+	min          string
+	oneOf        []string
+	pattern      *regexp.Regexp
+	rawValue     string
+	required     bool
+	requiredIf   map[string]string
+	requiredWith []string
+	schemes      []string
+	secretName   string
+	separator    string
+	short        string
+	source       string
+	sources      map[string]bool
+	value        reflect.Value
+	valueType    reflect.Type
 }
 
 /*
 New creates a new Container. This will verify that the struct
-field can be set and has the required tags.
+field can be set and has the required tags. An error is also
+returned if the default value cannot be converted to the field's type.
+
+The config value is the struct the field belongs to, and files holds
+the contents of the .env and config files. If a prefix is
+provided, such as "db", it is prepended to the flag name as "db-" and
+to the env name as "DB_".
 */
-func New(config interface{}, index int, envFile map[string]string) (*Container, error) {
+func New(config reflect.Value, index int, files Files, prefix string) (*Container, error) {
 	var (
 		hasFlag bool
 	)
 
-	t := reflect.TypeOf(config).Elem()
+	t := config.Type()
 
 	result := &Container{
-		config:    config,
-		envFile:   envFile,
-		fieldType: strings.ToLower(t.Field(index).Type.String()),
+		files:     files,
+		valueType: t.Field(index).Type,
 
-		configValue: reflect.ValueOf(config).Elem(),
+		configValue: config,
 		field:       t.Field(index),
 		fieldName:   t.Field(index).Name,
 	}
 
+	/*
+	 * Pointer fields are converted as the type they point to, and
+	 * are only allocated when a value is provided
+	 */
+	if result.valueType.Kind() == reflect.Ptr && result.valueType != locationType {
+		result.isPointer = true
+		result.valueType = result.valueType.Elem()
+	}
+
+	/*
+	 * Optional fields are converted as the type they wrap
+	 */
+	if reflect.PtrTo(result.valueType).Implements(optionalType) {
+		result.isOptional = true
+		result.valueType = reflect.New(result.valueType).Interface().(Optional).OptionalType()
+	}
+
+	result.fieldType = strings.ToLower(result.valueType.String())
+
 	/*
 	 * If this field doesn't have a flag name, or is private and
 	 * cannot be set, return an error
@@ -88,318 +310,1041 @@ func New(config interface{}, index int, envFile map[string]string) (*Container,
 		return result, ErrCantSet
 	}
 
-	result.flagName, hasFlag = result.field.Tag.Lookup(TagFlagName)
+	result.flagName, hasFlag = files.LookupTag(result.field, TagFlagName)
+	result.isRest, _ = strconv.ParseBool(files.Tag(result.field, TagRest))
+	tagged := hasFlag
+
+	if !hasFlag && !result.isRest && files.FlagNaming != nil {
+		result.flagName, hasFlag = files.FlagNaming(result.fieldName), true
+	}
 
-	if !hasFlag {
+	if !hasFlag && !result.isRest {
 		return result, ErrNoFlagName
 	}
 
 	result.fieldValue = result.configValue.Field(index)
-	result.envName = result.field.Tag.Get(TagEnvName)
-	result.defaultValue = result.field.Tag.Get(TagDefaultValue)
-	result.description = result.field.Tag.Get(TagDescription)
+	result.envName = files.Tag(result.field, TagEnvName)
 
-	if !flag.Parsed() {
-		result.addFlag()
-	}
-	result.SetDefaultValueOnConfig()
-	return result, nil
-}
+	if result.isRest {
+		if result.fieldType != "map[string]string" {
+			return result, fmt.Errorf("%w: rest fields must be map[string]string", ErrInvalid)
+		}
 
-func (c *Container) EnvBool() (bool, bool) {
-	value := os.Getenv(c.envName)
+		if prefix != "" && result.envName != "" {
+			result.envName = strings.ToUpper(strings.ReplaceAll(prefix, "-", "_")) + "_" + result.envName
+		}
 
-	if value != "" {
-		if result, err := strconv.ParseBool(value); err == nil {
-			return result, true
+		if files.EnvPrefix != "" && result.envName != "" {
+			result.envName = files.EnvPrefix + result.envName
 		}
+
+		result.fieldValue.Set(reflect.MakeMap(result.valueType))
+		return result, nil
 	}
 
-	return false, false
-}
+	/*
+	 * A field named by a naming strategy is skipped when its type isn't
+	 * supported, such as a logger, but a tagged field is a mistake
+	 */
+	if !result.IsSupported() {
+		if !tagged {
+			return result, ErrNoFlagName
+		}
+
+		return result, fmt.Errorf("%w: unsupported type %s", ErrInvalid, result.valueType)
+	}
+
+	if _, hasEnv := files.LookupTag(result.field, TagEnvName); !hasEnv && files.EnvNaming != nil {
+		result.envName = files.EnvNaming(result.fieldName)
+	}
+
+	/*
+	 * Env names after the first are checked in turn when it isn't set
+	 */
+	envNames := strings.Split(result.envName, ",")
+	result.envName = strings.TrimSpace(envNames[0])
 
-func (c *Container) EnvFloat() (float64, bool) {
-	value := os.Getenv(c.envName)
+	for _, alias := range envNames[1:] {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			result.envAliases = append(result.envAliases, alias)
+		}
+	}
 
-	if value != "" {
-		if result, err := strconv.ParseFloat(value, 64); err == nil {
-			return result, true
+	/*
+	 * Names after the first are old names kept working after a rename
+	 */
+	names := strings.Split(result.flagName, ",")
+	result.flagName = strings.TrimSpace(names[0])
+
+	for _, alias := range names[1:] {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			result.aliases = append(result.aliases, alias)
 		}
 	}
 
-	return 0.0, false
-}
+	result.fileKey = result.flagName
+	result.filePrefix = prefix
+
+	if prefix != "" {
+		result.flagName = prefix + "-" + result.flagName
+
+		for index, alias := range result.aliases {
+			result.aliases[index] = prefix + "-" + alias
+		}
+
+		if result.fileKey != "" {
+			result.fileKey = prefix + "-" + result.fileKey
+		}
+
+		envPrefix := strings.ToUpper(strings.ReplaceAll(prefix, "-", "_")) + "_"
 
-func (c *Container) EnvInt() (int, bool) {
-	value := os.Getenv(c.envName)
+		if result.envName != "" {
+			result.envName = envPrefix + result.envName
+		}
 
-	if value != "" {
-		if result, err := strconv.Atoi(value); err == nil {
-			return result, true
+		for index, alias := range result.envAliases {
+			result.envAliases[index] = envPrefix + alias
 		}
 	}
 
-	return 0, false
-}
+	if files.EnvPrefix != "" && result.envName != "" {
+		result.envName = files.EnvPrefix + result.envName
 
-func (c *Container) EnvString() (string, bool) {
-	value := os.Getenv(c.envName)
+		for index, alias := range result.envAliases {
+			result.envAliases[index] = files.EnvPrefix + alias
+		}
+	}
 
-	if value != "" {
-		return value, true
+	result.defaultValue, result.hasDefault = files.LookupTag(result.field, TagDefaultValue)
+	result.description = files.Tag(result.field, TagDescription)
+	result.separator = files.Tag(result.field, TagSeparator)
+	result.layout = files.Tag(result.field, TagLayout)
+	result.encoding = files.Tag(result.field, TagEncoding)
+	result.secretName = files.Tag(result.field, TagSecretFile)
+	result.short = files.Tag(result.field, TagShort)
+	result.duplicates = files.Tag(result.field, TagDuplicates)
+	result.group = files.Tag(result.field, TagGroup)
+	result.deprecated = files.Tag(result.field, TagDeprecated)
+	result.isCount, _ = strconv.ParseBool(files.Tag(result.field, TagCount))
+	result.required, _ = strconv.ParseBool(files.Tag(result.field, TagRequired))
+	result.bind, _ = strconv.ParseBool(files.Tag(result.field, TagBind))
+	result.min = files.Tag(result.field, TagMin)
+	result.max = files.Tag(result.field, TagMax)
+
+	if result.isCount && !result.IsInt() {
+		return result, fmt.Errorf("%w: count fields must be an int", ErrInvalid)
 	}
 
-	return value, false
-}
+	switch result.duplicates {
+	case "", DuplicatesLast, DuplicatesFirst, DuplicatesError:
+	default:
+		return result, fmt.Errorf("%w: duplicates must be %s, %s, or %s, not %q", ErrInvalid, DuplicatesLast, DuplicatesFirst, DuplicatesError, result.duplicates)
+	}
 
-func (c *Container) EnvTime() (time.Time, bool) {
-	value := os.Getenv(c.envName)
+	if err := result.checkRangeTags(); err != nil {
+		return result, err
+	}
 
-	if value != "" {
-		return c.parseTime(value), true
+	if err := result.compilePattern(files.Tag(result.field, TagPattern)); err != nil {
+		return result, err
 	}
 
-	return time.Time{}, false
-}
+	if err := result.parseOneOf(files.Tag(result.field, TagOneOf)); err != nil {
+		return result, err
+	}
+
+	if err := result.parsePathTags(files.Tag(result.field, TagFile), files.Tag(result.field, TagDir), files.Tag(result.field, TagMode)); err != nil {
+		return result, err
+	}
+
+	if err := result.parseHostname(files.Tag(result.field, TagHostname)); err != nil {
+		return result, err
+	}
 
-func (c *Container) EnvFileBool() (bool, bool) {
-	if value, ok := c.envFile[c.envName]; ok {
-		if result, err := strconv.ParseBool(value); err == nil {
-			return result, true
+	if err := result.parseSchemes(files.Tag(result.field, TagSchemes)); err != nil {
+		return result, err
+	}
+
+	if err := result.parseRequiredTags(files.Tag(result.field, TagRequiredIf), files.Tag(result.field, TagRequiredWith)); err != nil {
+		return result, err
+	}
+
+	if utf8.RuneCountInString(result.short) > 1 {
+		return result, fmt.Errorf("%w: short flag %q must be a single letter", ErrInvalid, result.short)
+	}
+
+	if result.secretName == "" {
+		result.secretName = result.envName
+	}
+
+	sources := files.Tag(result.field, TagSources)
+
+	if only := files.Tag(result.field, TagOnly); only != "" {
+		if sources != "" {
+			return result, fmt.Errorf("%w: use either the %s or %s tag", ErrInvalid, TagSources, TagOnly)
+		}
+
+		/*
+		 * A field that is only env is read from every source but its
+		 * flag, including custom sources. A field that is only flag is
+		 * read from every source but the environment, .env files, and
+		 * _FILE variables
+		 */
+		switch only {
+		case OnlyEnv:
+			result.excluded = map[string]bool{SourceFlag: true}
+
+		case OnlyFlag:
+			result.excluded = map[string]bool{SourceEnv: true, SourceEnvFile: true, SourceEnvRef: true}
+
+		default:
+			return result, fmt.Errorf("%w: %q is not env or flag", ErrInvalid, only)
 		}
 	}
 
-	return false, false
-}
+	if sources != "" {
+		result.sources = make(map[string]bool)
 
-func (c *Container) EnvFileFloat() (float64, bool) {
-	if value, ok := c.envFile[c.envName]; ok {
-		if result, err := strconv.ParseFloat(value, 64); err == nil {
-			return result, true
+		for _, source := range strings.Split(sources, ",") {
+			result.sources[strings.TrimSpace(source)] = true
 		}
 	}
 
-	return 0.0, false
-}
+	if result.separator == "" {
+		result.separator = DefaultSeparator
+	}
+
+	if err := result.SetDefaultValueOnConfig(); err != nil {
+		return result, err
+	}
 
-func (c *Container) EnvFileInt() (int, bool) {
-	if value, ok := c.envFile[c.envName]; ok {
-		if result, err := strconv.Atoi(value); err == nil {
-			return result, true
+	if !files.FlagSet().Parsed() {
+		if err := result.addFlag(); err != nil {
+			return result, err
 		}
 	}
 
-	return 0, false
+	return result, nil
 }
 
-func (c *Container) EnvFileString() (string, bool) {
-	if value, ok := c.envFile[c.envName]; ok {
-		return value, true
+/*
+AllowsSource returns true if the field may be set from the named source.
+The sources tag lists the sources allowed, such as "env,flag". Without
+it, every source is allowed, except the flag of an only:"env" field,
+and the environment of an only:"flag" field.
+*/
+func (c *Container) AllowsSource(name string) bool {
+	if c.excluded[name] {
+		return false
 	}
 
-	return "", false
+	return c.sources == nil || c.sources[name]
 }
 
-func (c *Container) EnvFileTime() (time.Time, bool) {
-	if value, ok := c.envFile[c.envName]; ok {
-		return c.parseTime(value), true
+/*
+Lookup returns the raw value for the field from a source's values, and
+true if it is present. Values are keyed by one of the field's env
+names, as they are in the environment and .env files.
+*/
+func (c *Container) Lookup(values map[string]string) (string, bool) {
+	for _, name := range c.EnvNames() {
+		if value, ok := values[name]; ok {
+			return value, true
+		}
 	}
 
-	return time.Time{}, false
+	return "", false
 }
 
-func (c *Container) FlagBool() (bool, bool) {
-	if c.boolValue != nil && *c.boolValue != c.defaultValueToBool() {
-		return *c.boolValue, true
+/*
+LookupFlag returns the raw value for the field from the flags given on
+the command line, and true if it is present. Values are keyed by the
+field's flag name, an old flag name, its short flag, or its no- form.
+*/
+func (c *Container) LookupFlag(values map[string]string) (string, bool) {
+	for _, name := range c.flagNames() {
+		if value, ok := values[name]; ok {
+			return value, true
+		}
 	}
 
-	return false, false
+	return "", false
 }
 
-func (c *Container) FlagFloat() (float64, bool) {
-	if c.floatValue != nil && *c.floatValue != c.defaultValueToFloat() {
-		return *c.floatValue, true
+/*
+FileRefValue returns the contents of the file named by the field's env
+name with a _FILE suffix, and true if it is set. For example, with
+DB_PASSWORD_FILE=/run/secrets/dbpass the value is read from that file,
+so secrets never appear in the environment. The .env file is checked
+after the environment, and trailing newlines are removed.
+*/
+func (c *Container) FileRefValue() (string, bool, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	for _, name := range c.EnvNames() {
+		fileName := os.Getenv(name + FileRefSuffix)
+
+		if fileName == "" {
+			if fileName = c.files.Env[name+FileRefSuffix]; fileName == "" {
+				continue
+			}
+		}
+
+		if data, err = os.ReadFile(fileName); err != nil {
+			return "", false, fmt.Errorf("error reading %s%s: %w", name, FileRefSuffix, err)
+		}
+
+		return strings.TrimRight(string(data), "\r\n"), true, nil
 	}
 
-	return 0.0, false
+	return "", false, nil
 }
 
-func (c *Container) FlagInt() (int, bool) {
-	if c.intValue != nil && *c.intValue != c.defaultValueToInt() {
-		return *c.intValue, true
+/*
+DefaultEnvValue returns the raw value for the field from the default
+env values, and true if the key is present.
+*/
+func (c *Container) DefaultEnvValue() (string, bool) {
+	for _, name := range c.EnvNames() {
+		if value, ok := c.files.DefaultEnv[name]; ok {
+			return value, true
+		}
 	}
 
-	return 0, false
+	return "", false
 }
 
-func (c *Container) FlagString() (string, bool) {
-	if c.stringValue != nil && *c.stringValue != c.defaultValueToString() {
-		return *c.stringValue, true
+/*
+SecretValue returns the contents of the field's secret file, and true
+if there is one. The file is named by the secretfile tag, or else the
+env name. As secrets are often named in lower case, the lower cased env
+name is tried too. Trailing newlines are removed. The file is read
+from the last secret dir that has it, and files that can't be read are
+skipped.
+*/
+func (c *Container) SecretValue() (string, bool) {
+	if c.secretName == "" {
+		return "", false
+	}
+
+	for index := len(c.files.SecretDirs) - 1; index >= 0; index-- {
+		for _, name := range []string{c.secretName, strings.ToLower(c.secretName)} {
+			if value, ok := env.ReadSecret(c.files.SecretDirs[index], name); ok {
+				return value, true
+			}
+		}
 	}
 
 	return "", false
 }
 
-func (c *Container) FlagTime() (time.Time, bool) {
-	if c.timeValue != nil && *c.timeValue != c.defaultValue {
-		return c.parseTime(*c.timeValue), true
+/*
+FileValue returns the value for the field from the config file, and
+true if the key is present. Lists are joined with the separator, and
+maps are written as key=value pairs.
+*/
+func (c *Container) FileValue() (string, bool) {
+	if c.fileKey == "" {
+		return "", false
+	}
+
+	value, ok := c.files.Config.Lookup(c.fileKey)
+
+	if !ok {
+		return "", false
 	}
 
-	return time.Time{}, false
+	return c.stringify(value), true
 }
 
 func (c *Container) IsBool() bool {
-	return c.fieldType == "bool"
+	return c.valueType.Kind() == reflect.Bool
+}
+
+/*
+IsBytes returns true for []byte fields, and named types of []byte.
+Values are decoded according to the encoding tag, or used as is when
+there isn't one.
+*/
+func (c *Container) IsBytes() bool {
+	return isBytes(c.valueType)
+}
+
+/*
+IsDecoded returns true if a decoder was registered for the field's type.
+*/
+func (c *Container) IsDecoded() bool {
+	_, ok := lookupDecoder(c.valueType)
+	return ok
+}
+
+/*
+IsDuration returns true for time.Duration fields. Values are parsed
+with time.ParseDuration, such as "1m30s".
+*/
+func (c *Container) IsDuration() bool {
+	return c.valueType == durationType
+}
+
+/*
+IsFlagValue returns true if the field implements flag.Value. These
+fields receive values from every source, the flags included, through
+their Set method.
+*/
+func (c *Container) IsFlagValue() bool {
+	return !c.isOptional && !c.IsDecoded() && c.implementsFlagValue()
 }
 
 func (c *Container) IsFloat() bool {
-	return c.fieldType == "float64"
+	return isFloatKind(c.valueType.Kind())
 }
 
+/*
+IsInt returns true for signed integer fields, including named types
+such as type Retries int, but not time.Duration.
+*/
 func (c *Container) IsInt() bool {
-	return c.fieldType == "int"
+	return isIntKind(c.valueType.Kind()) && !c.IsDuration()
 }
 
+/*
+IsSQLNull returns true for the database/sql Null types, such as
+sql.NullString or sql.NullInt64. Valid is only true when a value
+was provided.
+*/
+func (c *Container) IsSQLNull() bool {
+	return isSQLNull(c.valueType)
+}
+
+/*
+IsString returns true for string fields, including named types such as
+type Mode string.
+*/
 func (c *Container) IsString() bool {
-	return c.fieldType == "string"
+	return c.valueType.Kind() == reflect.String
+}
+
+/*
+IsLocation returns true for *time.Location fields. Values are
+time zone names, such as "America/Chicago", loaded with time.LoadLocation.
+*/
+func (c *Container) IsLocation() bool {
+	return c.valueType == locationType
+}
+
+/*
+IsMap returns true for maps with string keys, and string, numeric, or
+bool values. Values are pairs of key=value split on the separator,
+such as "read=100,write=20".
+*/
+func (c *Container) IsMap() bool {
+	if c.valueType.Kind() != reflect.Map || c.valueType.Key().Kind() != reflect.String {
+		return false
+	}
+
+	kind := c.valueType.Elem().Kind()
+	return kind == reflect.String || kind == reflect.Bool || isIntKind(kind) || isUintKind(kind) || isFloatKind(kind)
+}
+
+/*
+IsMailAddress returns true for mail.Address fields. Values are
+parsed as RFC 5322 addresses, such as "Alerts <alerts@example.com>".
+*/
+func (c *Container) IsMailAddress() bool {
+	return c.valueType == mailType
+}
+
+/*
+IsSlice returns true for slices of strings, numbers, or durations. Values for
+these fields are split on the separator.
+*/
+func (c *Container) IsSlice() bool {
+	if c.valueType.Kind() != reflect.Slice || c.IsBytes() {
+		return false
+	}
+
+	elem := c.valueType.Elem()
+
+	if _, ok := lookupDecoder(elem); ok {
+		return true
+	}
+
+	kind := elem.Kind()
+	return kind == reflect.String || isIntKind(kind) || isUintKind(kind) || isFloatKind(kind)
+}
+
+/*
+IsRest returns true for a catch-all map field, tagged with rest:"true".
+These collect env values that were not bound to any other field.
+*/
+func (c *Container) IsRest() bool {
+	return c.isRest
 }
 
 func (c *Container) IsTime() bool {
-	return c.fieldType == "time.time"
+	return c.valueType == timeType
+}
+
+func (c *Container) IsUint() bool {
+	return isUintKind(c.valueType.Kind())
 }
 
-func (c *Container) SetConfigBool(value bool) {
-	c.fieldValue.SetBool(value)
+/*
+IsUUID returns true for UUID fields. These are 16 byte arrays named
+like a UUID, which includes the UUID types of the popular uuid
+packages, or registered with RegisterUUID.
+*/
+func (c *Container) IsUUID() bool {
+	return isUUID(c.valueType)
+}
+
+/*
+IsSupported returns true if the field is of a type the container
+knows how to convert values into.
+*/
+func (c *Container) IsSupported() bool {
+	if c.isRest {
+		return false
+	}
+
+	return c.IsBool() || c.IsBytes() || c.IsDecoded() || c.IsDuration() || c.implementsFlagValue() || c.IsFloat() || c.IsInt() || c.IsLocation() || c.IsMap() || c.IsMailAddress() || c.IsSQLNull() || c.IsString() || c.IsSlice() || c.IsTime() || c.IsUint() || c.IsUUID()
 }
 
-func (c *Container) SetConfigFloat(value float64) {
-	c.fieldValue.SetFloat(value)
+/*
+SetConfigValue converts a raw value into the field's type and sets it
+on the config struct. The source is where the value came from, such as
+SourceEnv. An error is returned if the value cannot be converted, or
+doesn't fit in the field's type.
+*/
+func (c *Container) SetConfigValue(value, source string) error {
+	var (
+		err    error
+		result reflect.Value
+	)
+
+	if !c.IsSupported() {
+		return nil
+	}
+
+	if c.IsFlagValue() {
+		target := c.flagValueTarget()
+
+		if err = target.Set(value); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalid, err.Error())
+		}
+
+		/*
+		 * Record the parsed value so min, max, and oneof are
+		 * checked for flag.Value types too
+		 */
+		c.source = source
+		c.rawValue = value
+		c.value = reflect.ValueOf(target).Elem()
+		return nil
+	}
+
+	if result, err = c.convert(value); err != nil {
+		return err
+	}
+
+	c.source = source
+	c.rawValue = value
+	c.value = result
+
+	if c.isOptional {
+		c.fieldValue.Addr().Interface().(Optional).SetOptional(result.Interface(), source)
+		return nil
+	}
+
+	if c.isPointer {
+		pointer := reflect.New(c.valueType)
+		pointer.Elem().Set(result)
+		result = pointer
+	}
+
+	c.fieldValue.Set(result)
+	return nil
 }
 
-func (c *Container) SetConfigInt(value int) {
-	c.fieldValue.SetInt(int64(value))
+/*
+FieldName returns the name of the struct field.
+*/
+func (c *Container) FieldName() string {
+	return c.fieldName
 }
 
-func (c *Container) SetConfigString(value string) {
-	c.fieldValue.SetString(value)
+/*
+FlagName returns the name of the flag, including any prefix.
+*/
+func (c *Container) FlagName() string {
+	return c.flagName
 }
 
-func (c *Container) SetConfigTime(value time.Time) {
-	c.fieldValue.Set(reflect.ValueOf(value))
+/*
+EnvName returns the name of the field's environment variable. For
+rest fields this is the prefix of the variables collected.
+*/
+func (c *Container) EnvName() string {
+	return c.envName
 }
 
-func (c *Container) SetDefaultValueOnConfig() {
-	if c.IsBool() {
-		c.SetConfigBool(c.defaultValueToBool())
+/*
+EnvNames returns the names of the field's environment variables, in the
+order they are checked.
+*/
+func (c *Container) EnvNames() []string {
+	if c.envName == "" {
+		return nil
 	}
 
-	if c.IsFloat() {
-		c.SetConfigFloat(c.defaultValueToFloat())
+	return append([]string{c.envName}, c.envAliases...)
+}
+
+/*
+SetRestValues fills a rest field with env and .env values whose names
+start with the field's env prefix, and are not in bound. The prefix
+is removed from the keys. Without a prefix only .env values are
+collected, as the OS environment holds plenty of unrelated variables.
+*/
+func (c *Container) SetRestValues(bound map[string]bool) {
+	if !c.isRest {
+		return
 	}
 
-	if c.IsInt() {
-		c.SetConfigInt(c.defaultValueToInt())
+	add := func(key, value string) {
+		if bound[key] || !strings.HasPrefix(key, c.envName) || key == c.envName {
+			return
+		}
+
+		c.fieldValue.SetMapIndex(reflect.ValueOf(strings.TrimPrefix(key, c.envName)), reflect.ValueOf(value))
 	}
 
-	if c.IsString() {
-		c.SetConfigString(c.defaultValueToString())
+	if c.envName != "" {
+		for _, variable := range os.Environ() {
+			if pair := strings.SplitN(variable, "=", 2); len(pair) == 2 {
+				add(pair[0], pair[1])
+			}
+		}
 	}
 
-	if c.IsTime() {
-		c.SetConfigTime(c.defaultValueToTime())
+	for key, value := range c.files.Env {
+		add(key, value)
 	}
 }
 
-func (c *Container) addFlag() {
-	if c.IsBool() {
-		c.boolValue = flag.Bool(c.flagName, c.defaultValueToBool(), c.description)
+/*
+Deprecated returns the field's deprecated tag, which says what to use
+instead. It is empty if the field isn't deprecated.
+*/
+func (c *Container) Deprecated() string {
+	return c.deprecated
+}
+
+/*
+Source returns where the field's current value came from, such as
+SourceEnv. It is empty if no value has been set.
+*/
+func (c *Container) Source() string {
+	return c.source
+}
+
+/*
+SetDefaultValueOnConfig sets the field to the value of its default tag.
+Fields without a default are set to their zero value, except pointer
+fields which are left nil.
+*/
+func (c *Container) SetDefaultValueOnConfig() error {
+	if !c.IsSupported() {
+		return nil
+	}
+
+	if c.IsFlagValue() {
+		if c.defaultValue == "" {
+			return nil
+		}
+
+		return c.SetConfigValue(c.defaultValue, SourceDefault)
+	}
+
+	if c.isPointer {
+		if !c.hasDefault {
+			return nil
+		}
+
+		return c.SetConfigValue(c.defaultValue, SourceDefault)
 	}
 
-	if c.IsFloat() {
-		c.floatValue = flag.Float64(c.flagName, c.defaultValueToFloat(), c.description)
+	/*
+	 * Optional fields without a default are left unset, so their
+	 * source stays empty
+	 */
+	if c.isOptional && !c.hasDefault {
+		return nil
 	}
 
-	if c.IsInt() {
-		c.intValue = flag.Int(c.flagName, c.defaultValueToInt(), c.description)
+	if c.defaultValue == "" && !c.IsString() {
+		c.fieldValue.Set(reflect.Zero(c.fieldValue.Type()))
+		return nil
 	}
 
-	if c.IsString() {
-		c.stringValue = flag.String(c.flagName, c.defaultValueToString(), c.description)
+	if err := c.SetConfigValue(c.defaultValue, SourceDefault); err != nil {
+		return err
 	}
 
-	if c.IsTime() {
-		c.timeValue = flag.String(c.flagName, c.defaultValueToString(), c.description)
+	/*
+	 * Strings without a default are set to empty, which isn't a value
+	 * from anywhere
+	 */
+	if !c.hasDefault {
+		c.source = ""
 	}
+
+	return nil
 }
 
-func (c *Container) defaultValueToBool() bool {
+/*
+addFlag adds the field's flag to the flag set, along with its old names,
+which are marked deprecated, and its short flag if it has one. A name
+another field already uses is an error.
+*/
+func (c *Container) addFlag() error {
 	var (
-		err    error
-		result bool
+		value flag.Value
 	)
 
-	if result, err = strconv.ParseBool(c.defaultValue); err != nil {
+	if !c.IsSupported() || !c.AllowsSource(SourceFlag) {
+		return nil
+	}
+
+	flags := c.files.FlagSet()
+
+	for _, name := range c.flagNames() {
+		if existing := flags.Lookup(name); existing != nil {
+			return fmt.Errorf("%w: -%s is already defined", ErrFlagInUse, existing.Name)
+		}
+	}
+
+	c.flagValue = &flagValue{
+		container: c,
+		value:     c.defaultValue,
+	}
+
+	value = c.flagValue
+	flags.Var(value, c.flagName, c.description)
+
+	for _, alias := range c.aliases {
+		flags.Var(value, alias, fmt.Sprintf("Deprecated: use -%s", c.flagName))
+	}
+
+	if c.short != "" {
+		flags.Var(value, c.short, fmt.Sprintf("Short for -%s", c.flagName))
+	}
+
+	if c.isNegatable() {
+		flags.Var(&negatedFlagValue{target: c.flagValue}, c.negatedFlagName(), fmt.Sprintf("Turns off -%s", c.flagName))
+	}
+
+	return nil
+}
+
+/*
+isNegatable returns true for bool fields that default to true. These get
+a --no-<flag> form, as there's no other easy way to turn them off.
+*/
+func (c *Container) isNegatable() bool {
+	if !c.IsBool() || c.IsFlagValue() {
 		return false
 	}
 
+	enabled, _ := strconv.ParseBool(c.defaultValue)
+	return enabled
+}
+
+func (c *Container) negatedFlagName() string {
+	return "no-" + c.flagName
+}
+
+/*
+flagNames returns the names the field can be set with on the command
+line: its flag name, its old names, its short flag if it has one, and
+its --no-<flag> form if it is negatable.
+*/
+func (c *Container) flagNames() []string {
+	result := append([]string{c.flagName}, c.aliases...)
+
+	if c.short != "" {
+		result = append(result, c.short)
+	}
+
+	if c.isNegatable() {
+		result = append(result, c.negatedFlagName())
+	}
+
 	return result
 }
 
-func (c *Container) defaultValueToFloat() float64 {
+/*
+convert turns a raw value into the field's type. Slice fields are split
+on the separator, and each element converted on its own. An empty value
+is an empty slice. Errors for an element include its index.
+*/
+func (c *Container) convert(value string) (reflect.Value, error) {
 	var (
-		err    error
-		result float64
+		err     error
+		result  reflect.Value
+		element reflect.Value
 	)
 
-	if result, err = strconv.ParseFloat(c.defaultValue, 64); err != nil {
-		return 0.0
+	if c.IsMap() {
+		return c.convertMap(value)
 	}
 
-	return result
+	if c.valueType.Kind() != reflect.Slice || c.IsBytes() || c.IsDecoded() {
+		if result, err = c.convertElement(c.valueType, value); err != nil {
+			return result, err
+		}
+
+		return result, nil
+	}
+
+	if strings.TrimSpace(value) == "" {
+		return reflect.MakeSlice(c.valueType, 0, 0), nil
+	}
+
+	parts := strings.Split(value, c.separator)
+	result = reflect.MakeSlice(c.valueType, 0, len(parts))
+
+	for index, part := range parts {
+		if element, err = c.convertElement(c.valueType.Elem(), strings.TrimSpace(part)); err != nil {
+			return result, fmt.Errorf("element %d: %w", index, err)
+		}
+
+		result = reflect.Append(result, element)
+	}
+
+	return result, nil
+}
+
+/*
+FileKey returns the key the field is found under in a config file of
+the given format. This is the name from the tag for the format, such as
+yaml, or the flag name. A tag of "-" means the field isn't read from
+files of that format, and an empty key is returned. Config files are
+re-keyed by flag name when read, so FileValue only needs the flag name.
+*/
+func (c *Container) FileKey(format string) string {
+	if c.fileKey == "" {
+		return ""
+	}
+
+	tag, ok := c.field.Tag.Lookup(format)
+
+	if !ok {
+		return c.fileKey
+	}
+
+	name := strings.Split(tag, ",")[0]
+
+	if name == "-" {
+		return ""
+	}
+
+	if name == "" {
+		return c.fileKey
+	}
+
+	if c.filePrefix != "" {
+		name = c.filePrefix + "-" + name
+	}
+
+	return name
+}
+
+/*
+stringify turns a value from a config file into a raw value.
+*/
+func (c *Container) stringify(value interface{}) string {
+	switch typed := value.(type) {
+	case []interface{}:
+		parts := make([]string, 0, len(typed))
+
+		for _, item := range typed {
+			parts = append(parts, c.stringify(item))
+		}
+
+		return strings.Join(parts, c.separator)
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+
+		for key := range typed {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+
+		for _, key := range keys {
+			parts = append(parts, key+"="+c.stringify(typed[key]))
+		}
+
+		return strings.Join(parts, c.separator)
+	}
+
+	return fmt.Sprint(value)
 }
 
-func (c *Container) defaultValueToInt() int {
+/*
+convertMap converts a value of key=value pairs, split on the separator,
+into the field's map type. Errors for a value include its key. A key
+given more than once is handled as the duplicates tag says: the last
+value wins, the first value wins, or it is an error.
+*/
+func (c *Container) convertMap(value string) (reflect.Value, error) {
 	var (
-		err    error
-		result int
+		err     error
+		element reflect.Value
 	)
 
-	if result, err = strconv.Atoi(c.defaultValue); err != nil {
-		return 0
+	result := reflect.MakeMap(c.valueType)
+
+	if strings.TrimSpace(value) == "" {
+		return result, nil
 	}
 
-	return result
-}
+	for _, part := range strings.Split(value, c.separator) {
+		pair := strings.SplitN(part, "=", 2)
+
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" {
+			return result, fmt.Errorf("%w: %q is not a key=value pair", ErrInvalid, strings.TrimSpace(part))
+		}
+
+		key := strings.TrimSpace(pair[0])
+		mapKey := reflect.ValueOf(key).Convert(c.valueType.Key())
+
+		if element, err = c.convertElement(c.valueType.Elem(), strings.TrimSpace(pair[1])); err != nil {
+			return result, fmt.Errorf("key %q: %w", key, err)
+		}
 
-func (c *Container) defaultValueToString() string {
-	return c.defaultValue
+		if result.MapIndex(mapKey).IsValid() {
+			if c.duplicates == DuplicatesError {
+				return result, fmt.Errorf("%w: key %q is given more than once", ErrInvalid, key)
+			}
+
+			if c.duplicates == DuplicatesFirst {
+				continue
+			}
+		}
+
+		result.SetMapIndex(mapKey, element)
+	}
+
+	return result, nil
 }
 
-func (c *Container) defaultValueToTime() time.Time {
-	if !c.isTime(c.defaultValue) {
-		return time.Time{}
+/*
+convertElement converts a single value, applying any tags that change
+how the value is parsed, such as a time layout.
+*/
+func (c *Container) convertElement(t reflect.Type, value string) (reflect.Value, error) {
+	if t == timeType && c.layout != "" {
+		parsed, err := time.Parse(c.layout, value)
+
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w: %q does not match layout %q", ErrInvalid, value, c.layout)
+		}
+
+		return reflect.ValueOf(parsed), nil
 	}
 
-	result := c.parseTime(c.defaultValue)
-	return result
+	if isBytes(t) {
+		result, err := c.decodeBytes(value)
+
+		if err != nil {
+			return result, err
+		}
+
+		return result.Convert(t), nil
+	}
+
+	return convert(t, value)
 }
 
-func (c *Container) isTime(value string) bool {
-	for _, f := range timeFormats {
-		if _, err := time.Parse(f, value); err == nil {
-			return true
+/*
+decodeBytes decodes a value for a []byte field using the encoding tag.
+Supported encodings are "base64", "base64url", and "hex".
+*/
+func (c *Container) decodeBytes(value string) (reflect.Value, error) {
+	var (
+		err    error
+		result []byte
+	)
+
+	switch c.encoding {
+	case "":
+		result = []byte(value)
+
+	case "base64":
+		if result, err = base64.StdEncoding.DecodeString(value); err != nil {
+			result, err = base64.RawStdEncoding.DecodeString(value)
+		}
+
+	case "base64url":
+		if result, err = base64.URLEncoding.DecodeString(value); err != nil {
+			result, err = base64.RawURLEncoding.DecodeString(value)
 		}
+
+	case "hex":
+		result, err = hex.DecodeString(value)
+
+	default:
+		return reflect.Value{}, fmt.Errorf("%w: unknown encoding %q", ErrInvalid, c.encoding)
+	}
+
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: value is not valid %s", ErrInvalid, c.encoding)
 	}
 
-	return false
+	return reflect.ValueOf(result), nil
+}
+
+func (c *Container) implementsFlagValue() bool {
+	return reflect.PtrTo(c.valueType).Implements(flagValueType)
 }
 
-func (c *Container) parseTime(value string) time.Time {
-	for _, f := range timeFormats {
-		if t, err := time.Parse(f, value); err == nil {
-			return t
+/*
+flagValueTarget returns the field as a flag.Value, allocating it first
+if the field is a nil pointer.
+*/
+func (c *Container) flagValueTarget() flag.Value {
+	if c.isPointer {
+		if c.fieldValue.IsNil() {
+			c.fieldValue.Set(reflect.New(c.valueType))
 		}
+
+		return c.fieldValue.Interface().(flag.Value)
+	}
+
+	return c.fieldValue.Addr().Interface().(flag.Value)
+}
+
+/*
+RawFlagValue returns the raw string provided for a flag defined by a
+container, and true if the flag.Value is one of them. This includes
+fields of flag.Value types, which are Set when the flag layer is
+applied rather than while the flags are parsed.
+*/
+func RawFlagValue(value flag.Value) (string, bool) {
+	if f, ok := value.(*flagValue); ok && f.set {
+		return f.value, true
 	}
 
-	return time.Time{}
+	if f, ok := value.(*negatedFlagValue); ok && f.target.set {
+		return f.target.value, true
+	}
+
+	return "", false
 }
@@ -0,0 +1,267 @@
+package container
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	locationType = reflect.TypeOf(&time.Location{})
+	mailType     = reflect.TypeOf(mail.Address{})
+	timeType     = reflect.TypeOf(time.Time{})
+
+	timeFormats = []string{
+		"2006-01-02",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04:05Z",
+		"2006-01-02T15:04:05 MST",
+		"2006-01-02T15:04:05-0700",
+	}
+)
+
+/*
+convert turns a raw string value into a reflect.Value of the
+provided type. Numeric values are parsed using the bit size of the
+type, so a value that doesn't fit returns ErrOverflow rather than
+being truncated.
+*/
+func convert(t reflect.Type, value string) (reflect.Value, error) {
+	result := reflect.New(t).Elem()
+
+	if decoder, ok := lookupDecoder(t); ok {
+		return decode(t, decoder, value)
+	}
+
+	if reflect.PtrTo(t).Implements(flagValueType) {
+		if err := result.Addr().Interface().(flag.Value).Set(value); err != nil {
+			return result, fmt.Errorf("%w: %s", ErrInvalid, err.Error())
+		}
+
+		return result, nil
+	}
+
+	if t == timeType {
+		parsed, err := parseTime(value)
+
+		if err != nil {
+			return result, err
+		}
+
+		result.Set(reflect.ValueOf(parsed))
+		return result, nil
+	}
+
+	if t == durationType {
+		duration, err := time.ParseDuration(value)
+
+		if err != nil {
+			return result, fmt.Errorf("%w: %q is not a valid duration", ErrInvalid, value)
+		}
+
+		result.SetInt(int64(duration))
+		return result, nil
+	}
+
+	if t == locationType {
+		location, err := time.LoadLocation(value)
+
+		if err != nil {
+			return result, fmt.Errorf("%w: %q is not a known time zone", ErrInvalid, value)
+		}
+
+		result.Set(reflect.ValueOf(location))
+		return result, nil
+	}
+
+	if t == mailType {
+		address, err := mail.ParseAddress(value)
+
+		if err != nil {
+			return result, fmt.Errorf("%w: %q is not a valid email address", ErrInvalid, value)
+		}
+
+		result.Set(reflect.ValueOf(*address))
+		return result, nil
+	}
+
+	if isSQLNull(t) {
+		inner, err := convert(t.Field(0).Type, value)
+
+		if err != nil {
+			return result, err
+		}
+
+		result.Field(0).Set(inner)
+		result.FieldByName("Valid").SetBool(true)
+		return result, nil
+	}
+
+	if isUUID(t) {
+		uuid, err := parseUUID(value)
+
+		if err != nil {
+			return result, err
+		}
+
+		result.Set(reflect.ValueOf(uuid).Convert(t))
+		return result, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := parseBool(value)
+
+		if err != nil {
+			return result, conversionError(t, value, err)
+		}
+
+		result.SetBool(b)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, t.Bits())
+
+		if err != nil {
+			return result, conversionError(t, value, err)
+		}
+
+		result.SetFloat(f)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 0, t.Bits())
+
+		if err != nil {
+			if size, ok := parseByteSize(value); ok {
+				return convertByteSize(t, value, size)
+			}
+
+			return result, conversionError(t, value, err)
+		}
+
+		result.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 0, t.Bits())
+
+		if err != nil {
+			if size, ok := parseByteSize(value); ok {
+				return convertByteSize(t, value, size)
+			}
+
+			return result, conversionError(t, value, err)
+		}
+
+		result.SetUint(u)
+
+	case reflect.String:
+		result.SetString(value)
+
+	default:
+		return result, fmt.Errorf("%w: unsupported type %s", ErrInvalid, t)
+	}
+
+	return result, nil
+}
+
+/*
+convertByteSize sets a number of bytes on an integer type, returning
+ErrOverflow if it doesn't fit.
+*/
+func convertByteSize(t reflect.Type, value string, size float64) (reflect.Value, error) {
+	result := reflect.New(t).Elem()
+
+	if t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64 {
+		if size >= math.Exp2(float64(t.Bits())) {
+			return result, conversionError(t, value, strconv.ErrRange)
+		}
+
+		result.SetUint(uint64(size))
+		return result, nil
+	}
+
+	if size >= math.Exp2(float64(t.Bits()-1)) {
+		return result, conversionError(t, value, strconv.ErrRange)
+	}
+
+	result.SetInt(int64(size))
+	return result, nil
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uint64
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+/*
+isBytes returns true for []byte, and named types of it.
+*/
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+/*
+isSQLNull returns true for the database/sql Null types, such as
+sql.NullString. The first field holds the value, and Valid is set
+when a value is converted.
+*/
+func isSQLNull(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null") {
+		return false
+	}
+
+	_, hasValid := t.FieldByName("Valid")
+	return hasValid && t.NumField() == 2
+}
+
+func conversionError(t reflect.Type, value string, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("%w: %q does not fit in %s", ErrOverflow, value, t)
+	}
+
+	return fmt.Errorf("%w: %q is not a valid %s", ErrInvalid, value, t)
+}
+
+/*
+parseBool accepts yes/no, on/off, and y/n, in any case, in addition to
+the values accepted by strconv.ParseBool.
+*/
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "y", "on":
+		return true, nil
+
+	case "no", "n", "off":
+		return false, nil
+	}
+
+	return strconv.ParseBool(strings.ToLower(strings.TrimSpace(value)))
+}
+
+/*
+parseTime parses a value using the first of the default time formats
+that matches. Fields that need a different format can use the layout tag.
+*/
+func parseTime(value string) (time.Time, error) {
+	for _, f := range timeFormats {
+		if t, err := time.Parse(f, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q does not match any supported time format", ErrInvalid, value)
+}
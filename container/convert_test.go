@@ -0,0 +1,67 @@
+package container
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type namedUUID [16]byte
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name  string
+		t     reflect.Type
+		value string
+		want  interface{}
+		err   error
+	}{
+		{name: "string", t: reflect.TypeOf(""), value: "hello", want: "hello"},
+		{name: "bool", t: reflect.TypeOf(false), value: "true", want: true},
+		{name: "bool yes", t: reflect.TypeOf(false), value: "yes", want: true},
+		{name: "bool invalid", t: reflect.TypeOf(false), value: "maybe", err: ErrInvalid},
+		{name: "int", t: reflect.TypeOf(0), value: "42", want: 42},
+		{name: "int hex", t: reflect.TypeOf(0), value: "0x10", want: 16},
+		{name: "int invalid", t: reflect.TypeOf(0), value: "forty", err: ErrInvalid},
+		{name: "int8 overflow", t: reflect.TypeOf(int8(0)), value: "128", err: ErrOverflow},
+		{name: "int8 min", t: reflect.TypeOf(int8(0)), value: "-128", want: int8(-128)},
+		{name: "uint16", t: reflect.TypeOf(uint16(0)), value: "65535", want: uint16(65535)},
+		{name: "uint16 overflow", t: reflect.TypeOf(uint16(0)), value: "65536", err: ErrOverflow},
+		{name: "uint negative", t: reflect.TypeOf(uint(0)), value: "-1", err: ErrInvalid},
+		{name: "int byte size", t: reflect.TypeOf(int64(0)), value: "10MB", want: int64(10000000)},
+		{name: "uint byte size", t: reflect.TypeOf(uint64(0)), value: "1KiB", want: uint64(1024)},
+		{name: "byte size overflow", t: reflect.TypeOf(uint8(0)), value: "1KiB", err: ErrOverflow},
+		{name: "float32", t: reflect.TypeOf(float32(0)), value: "1.5", want: float32(1.5)},
+		{name: "float64", t: reflect.TypeOf(float64(0)), value: "2.25", want: 2.25},
+		{name: "duration", t: reflect.TypeOf(time.Duration(0)), value: "1m30s", want: 90 * time.Second},
+		{name: "duration invalid", t: reflect.TypeOf(time.Duration(0)), value: "soon", err: ErrInvalid},
+		{name: "time", t: reflect.TypeOf(time.Time{}), value: "2024-01-02", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "uuid", t: reflect.TypeOf(namedUUID{}), value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", want: namedUUID{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}},
+		{name: "uuid braces", t: reflect.TypeOf(namedUUID{}), value: "{6BA7B810-9DAD-11D1-80B4-00C04FD430C8}", want: namedUUID{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}},
+		{name: "uuid invalid", t: reflect.TypeOf(namedUUID{}), value: "6ba7b810", err: ErrInvalid},
+		{name: "unsupported", t: reflect.TypeOf(make(chan int)), value: "1", err: ErrInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := convert(test.t, test.value)
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected error %v, got %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got.Interface(), test.want) {
+				t.Errorf("expected %#v, got %#v", test.want, got.Interface())
+			}
+		})
+	}
+}
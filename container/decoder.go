@@ -0,0 +1,66 @@
+package container
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+/*
+Decoder converts a raw value into a value of a registered type.
+*/
+type Decoder func(value string) (interface{}, error)
+
+var (
+	decoders     = make(map[reflect.Type]Decoder)
+	decodersLock sync.RWMutex
+)
+
+/*
+RegisterDecoder teaches the container how to convert values into a
+type. Registered decoders take precedence over the built in conversions.
+*/
+func RegisterDecoder(t reflect.Type, decoder Decoder) {
+	decodersLock.Lock()
+	defer decodersLock.Unlock()
+
+	decoders[t] = decoder
+}
+
+func lookupDecoder(t reflect.Type) (Decoder, bool) {
+	decodersLock.RLock()
+	defer decodersLock.RUnlock()
+
+	decoder, ok := decoders[t]
+	return decoder, ok
+}
+
+/*
+decode runs a registered decoder, and makes sure the value it returns
+is of the type it was registered for.
+*/
+func decode(t reflect.Type, decoder Decoder, value string) (reflect.Value, error) {
+	var (
+		err     error
+		decoded interface{}
+	)
+
+	result := reflect.New(t).Elem()
+
+	if decoded, err = decoder(value); err != nil {
+		return result, fmt.Errorf("%w: %s", ErrInvalid, err.Error())
+	}
+
+	if decoded == nil {
+		return result, nil
+	}
+
+	v := reflect.ValueOf(decoded)
+
+	if !v.Type().ConvertibleTo(t) {
+		return result, fmt.Errorf("%w: decoder for %s returned a %s", ErrInvalid, t, v.Type())
+	}
+
+	result.Set(v.Convert(t))
+	return result, nil
+}
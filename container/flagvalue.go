@@ -0,0 +1,108 @@
+package container
+
+import (
+	"reflect"
+	"strconv"
+)
+
+/*
+flagValue is a flag.Value that captures the raw string provided on
+the command line for a container. The value is converted to the
+field's type when the flag layer is applied, like a value from any
+other source, so @file values are read and enc: values decrypted
+first. Slice and map fields accumulate repeated occurrences of the
+flag, and counting fields count them.
+*/
+type flagValue struct {
+	container *Container
+	set       bool
+	value     string
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	if f.container == nil {
+		return false
+	}
+
+	if f.container.IsFlagValue() {
+		boolFlag, ok := reflect.New(f.container.valueType).Interface().(interface{ IsBoolFlag() bool })
+		return ok && boolFlag.IsBoolFlag()
+	}
+
+	if f.container.IsSQLNull() {
+		return f.container.valueType.Field(0).Type.Kind() == reflect.Bool
+	}
+
+	return f.container.IsBool() || f.container.isCount
+}
+
+func (f *flagValue) Set(value string) error {
+	if f.container.isCount {
+		return f.increment(value)
+	}
+
+	if f.set && (f.container.IsSlice() || f.container.IsMap()) {
+		f.value += f.container.separator + value
+	} else {
+		f.value = value
+	}
+
+	f.set = true
+	return nil
+}
+
+func (f *flagValue) String() string {
+	return f.value
+}
+
+/*
+increment counts an occurrence of a counting flag, so -v -v, or -vv with
+GNU flags, gives 2. A number, as in -v=3, sets the count.
+*/
+func (f *flagValue) increment(value string) error {
+	count := 0
+
+	if value != "true" {
+		if _, err := f.container.convert(value); err != nil {
+			return err
+		}
+
+		f.value = value
+		f.set = true
+		return nil
+	}
+
+	if f.set {
+		count, _ = strconv.Atoi(f.value)
+	}
+
+	f.value = strconv.Itoa(count + 1)
+	f.set = true
+	return nil
+}
+
+/*
+negatedFlagValue is the --no-<flag> form of a bool flag that defaults
+to true. Setting it sets the field's flag to the opposite value.
+*/
+type negatedFlagValue struct {
+	target *flagValue
+}
+
+func (f *negatedFlagValue) IsBoolFlag() bool {
+	return true
+}
+
+func (f *negatedFlagValue) Set(value string) error {
+	enabled, err := strconv.ParseBool(value)
+
+	if err != nil {
+		return err
+	}
+
+	return f.target.Set(strconv.FormatBool(!enabled))
+}
+
+func (f *negatedFlagValue) String() string {
+	return ""
+}
@@ -0,0 +1,70 @@
+package container
+
+import (
+	"strings"
+)
+
+/*
+Usage describes how a field is set, for help output. Flag is empty
+when the field has no flag, such as a field that may only be set from
+the environment. Negated is the --no-<flag> form of a bool field that
+defaults to true. Group is the heading the field is listed under.
+*/
+type Usage struct {
+	Group       string
+	Flag        string
+	Short       string
+	Negated     string
+	Aliases     []string
+	Env         []string
+	Default     string
+	Description string
+	Deprecated  string
+	Required    bool
+	OneOf       []string
+	Type        string
+	IsBool      bool
+}
+
+/*
+Usage returns how the field is set, for help output.
+*/
+func (c *Container) Usage() Usage {
+	result := Usage{
+		Group:       c.group,
+		Env:         c.EnvNames(),
+		Default:     c.defaultValue,
+		Description: c.description,
+		Deprecated:  c.deprecated,
+		Required:    c.required,
+		OneOf:       c.oneOf,
+		Type:        c.typeName(),
+		IsBool:      c.IsBool() || c.isCount,
+	}
+
+	if c.AllowsSource(SourceFlag) {
+		result.Flag = c.flagName
+		result.Short = c.short
+		result.Aliases = c.aliases
+
+		if c.isNegatable() {
+			result.Negated = c.negatedFlagName()
+		}
+	}
+
+	return result
+}
+
+/*
+typeName returns a short name for the field's type, such as duration
+for time.Duration, to show what a flag expects.
+*/
+func (c *Container) typeName() string {
+	name := c.fieldType
+
+	if index := strings.LastIndex(name, "."); index != -1 && !strings.HasPrefix(name, "map[") && !strings.HasPrefix(name, "[]") {
+		name = name[index+1:]
+	}
+
+	return name
+}
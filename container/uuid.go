@@ -0,0 +1,74 @@
+package container
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	uuidTypes     = make(map[reflect.Type]bool)
+	uuidTypesLock sync.RWMutex
+)
+
+/*
+RegisterUUID marks a 16 byte array type as a UUID, for UUID types whose
+name doesn't say so.
+*/
+func RegisterUUID(t reflect.Type) {
+	uuidTypesLock.Lock()
+	defer uuidTypesLock.Unlock()
+
+	uuidTypes[t] = true
+}
+
+/*
+isUUID returns true for 16 byte array types named like a UUID, such as
+uuid.UUID from github.com/google/uuid and github.com/gofrs/uuid, or
+registered with RegisterUUID. Other 16 byte arrays, such as a [16]byte
+hash or key, aren't UUIDs.
+*/
+func isUUID(t reflect.Type) bool {
+	if t.Kind() != reflect.Array || t.Len() != 16 || t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+
+	if strings.Contains(strings.ToUpper(t.Name()), "UUID") {
+		return true
+	}
+
+	uuidTypesLock.RLock()
+	defer uuidTypesLock.RUnlock()
+
+	return uuidTypes[t]
+}
+
+/*
+parseUUID parses a UUID in its canonical form, such as
+"6ba7b810-9dad-11d1-80b4-00c04fd430c8". Braces and a "urn:uuid:"
+prefix are also accepted.
+*/
+func parseUUID(value string) ([16]byte, error) {
+	var (
+		err    error
+		result [16]byte
+	)
+
+	trimmed := strings.TrimPrefix(strings.ToLower(value), "urn:uuid:")
+
+	if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+
+	if len(trimmed) != 36 || trimmed[8] != '-' || trimmed[13] != '-' || trimmed[18] != '-' || trimmed[23] != '-' {
+		return result, fmt.Errorf("%w: %q is not a valid UUID", ErrInvalid, value)
+	}
+
+	if _, err = hex.Decode(result[:], []byte(strings.ReplaceAll(trimmed, "-", ""))); err != nil {
+		return result, fmt.Errorf("%w: %q is not a valid UUID", ErrInvalid, value)
+	}
+
+	return result, nil
+}
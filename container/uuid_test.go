@@ -0,0 +1,27 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsUUID(t *testing.T) {
+	tests := []struct {
+		name string
+		t    reflect.Type
+		want bool
+	}{
+		{name: "named UUID", t: reflect.TypeOf(namedUUID{}), want: true},
+		{name: "plain array", t: reflect.TypeOf([16]byte{}), want: false},
+		{name: "wrong length", t: reflect.TypeOf([8]byte{}), want: false},
+		{name: "string", t: reflect.TypeOf(""), want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isUUID(test.t); got != test.want {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
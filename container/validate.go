@@ -0,0 +1,644 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Validate checks the field's value against its validation tags, such
+as min, max, pattern, oneof, bind, schemes, hostname, file, and dir.
+Fields that weren't given a value aren't checked, which is left to the
+required tag. The context limits DNS lookups.
+*/
+func (c *Container) Validate(ctx context.Context) error {
+	if c.Source() == "" {
+		return nil
+	}
+
+	if !c.value.IsValid() {
+		return nil
+	}
+
+	if err := c.checkBind(); err != nil {
+		return err
+	}
+
+	if err := c.checkSchemes(); err != nil {
+		return err
+	}
+
+	if err := c.checkRange(); err != nil {
+		return err
+	}
+
+	if err := c.checkPattern(); err != nil {
+		return err
+	}
+
+	if err := c.checkOneOf(); err != nil {
+		return err
+	}
+
+	if err := c.checkHostname(ctx); err != nil {
+		return err
+	}
+
+	return c.checkPaths()
+}
+
+/*
+checkRangeTags makes sure min and max are only used on numbers and
+durations, and that they convert to the field's type, so a bad tag is
+found before any value is loaded.
+*/
+func (c *Container) checkRangeTags() error {
+	if c.min == "" && c.max == "" {
+		return nil
+	}
+
+	if !c.IsInt() && !c.IsUint() && !c.IsFloat() && !c.IsDuration() {
+		return fmt.Errorf("%w: %s and %s are only for numbers and durations", ErrInvalid, TagMin, TagMax)
+	}
+
+	for _, bound := range []string{c.min, c.max} {
+		if bound == "" {
+			continue
+		}
+
+		if _, err := c.convertElement(c.valueType, bound); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+checkRange returns ErrOutOfRange when the value is below the min tag
+or above the max tag.
+*/
+func (c *Container) checkRange() error {
+	if c.min != "" {
+		bound, _ := c.convertElement(c.valueType, c.min)
+
+		if compareNumbers(c.value, bound) < 0 {
+			return fmt.Errorf("%w: %s is less than the minimum of %s", ErrOutOfRange, c.rawValue, c.min)
+		}
+	}
+
+	if c.max != "" {
+		bound, _ := c.convertElement(c.valueType, c.max)
+
+		if compareNumbers(c.value, bound) > 0 {
+			return fmt.Errorf("%w: %s is more than the maximum of %s", ErrOutOfRange, c.rawValue, c.max)
+		}
+	}
+
+	return nil
+}
+
+/*
+checkBind returns ErrInvalid when the field has a bind tag and its
+port can't be listened on, such as when another process has it. The
+port is the field's value as it prints, so this works for int fields
+as well as configinator.Port.
+*/
+func (c *Container) checkBind() error {
+	if !c.bind {
+		return nil
+	}
+
+	port := valueString(c.value)
+	listener, err := net.Listen("tcp", net.JoinHostPort("", port))
+
+	if err != nil {
+		return fmt.Errorf("%w: port %s can't be listened on: %s", ErrInvalid, port, err.Error())
+	}
+
+	return listener.Close()
+}
+
+/*
+parsePathTags checks the file and dir tags, and parses the mode tag,
+an octal mode such as 0700 for directories created by a dir tag of
+create. Paths are only strings, or slices of strings.
+*/
+func (c *Container) parsePathTags(file, dir, mode string) error {
+	c.file = file
+	c.dir = dir
+	c.dirMode = DefaultDirMode
+
+	if file == "" && dir == "" {
+		return nil
+	}
+
+	if c.elementType().Kind() != reflect.String {
+		return fmt.Errorf("%w: %s and %s are only for strings", ErrInvalid, TagFile, TagDir)
+	}
+
+	if file != "" && file != PathExists {
+		return fmt.Errorf("%w: %s must be %s, not %q", ErrInvalid, TagFile, PathExists, file)
+	}
+
+	if dir != "" && dir != PathExists && dir != PathCreate {
+		return fmt.Errorf("%w: %s must be %s or %s, not %q", ErrInvalid, TagDir, PathExists, PathCreate, dir)
+	}
+
+	if mode != "" {
+		parsed, err := strconv.ParseUint(mode, 8, 32)
+
+		if err != nil {
+			return fmt.Errorf("%w: %s %q is not an octal mode", ErrInvalid, TagMode, mode)
+		}
+
+		c.dirMode = os.FileMode(parsed)
+	}
+
+	return nil
+}
+
+/*
+checkPaths returns ErrInvalid when a field with a file tag doesn't
+name a file, or one with a dir tag doesn't name a directory. With a
+dir tag of create, missing directories are created, along with any
+parents.
+*/
+func (c *Container) checkPaths() error {
+	if c.file == "" && c.dir == "" {
+		return nil
+	}
+
+	for _, value := range c.elements() {
+		path := value.String()
+
+		if path == "" {
+			continue
+		}
+
+		info, err := os.Stat(path)
+
+		if errors.Is(err, fs.ErrNotExist) && c.dir == PathCreate {
+			if err = os.MkdirAll(path, c.dirMode); err != nil {
+				return fmt.Errorf("%w: error creating directory %s: %s", ErrInvalid, path, err.Error())
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalid, err.Error())
+		}
+
+		if c.file != "" && info.IsDir() {
+			return fmt.Errorf("%w: %s is a directory, not a file", ErrInvalid, path)
+		}
+
+		if c.dir != "" && !info.IsDir() {
+			return fmt.Errorf("%w: %s is not a directory", ErrInvalid, path)
+		}
+	}
+
+	return nil
+}
+
+/*
+parseSchemes splits the schemes tag into the URL schemes allowed. URLs
+are strings, slices of strings, or flag.Value types such as
+configinator.ConnectionURL.
+*/
+func (c *Container) parseSchemes(schemes string) error {
+	if schemes == "" {
+		return nil
+	}
+
+	if c.elementType().Kind() != reflect.String && !c.IsFlagValue() {
+		return fmt.Errorf("%w: %s is only for strings and URL types", ErrInvalid, TagSchemes)
+	}
+
+	for _, scheme := range strings.Split(schemes, ",") {
+		c.schemes = append(c.schemes, strings.ToLower(strings.TrimSpace(scheme)))
+	}
+
+	return nil
+}
+
+/*
+checkSchemes returns ErrInvalid when a URL's scheme isn't one of those
+in the schemes tag. Only the scheme is named in the error, so a
+password in the URL isn't shown.
+*/
+func (c *Container) checkSchemes() error {
+	if len(c.schemes) == 0 {
+		return nil
+	}
+
+	for _, value := range c.elements() {
+		text := valueString(value)
+
+		if text == "" {
+			continue
+		}
+
+		parsed, err := url.Parse(text)
+
+		if err != nil {
+			return fmt.Errorf("%w: not a valid URL", ErrInvalid)
+		}
+
+		if !c.allowsScheme(strings.ToLower(parsed.Scheme)) {
+			return fmt.Errorf("%w: URL scheme %q is not allowed, expected %s", ErrInvalid, parsed.Scheme, strings.Join(c.schemes, " or "))
+		}
+	}
+
+	return nil
+}
+
+/*
+valueString returns a value as it prints, using its String method when
+it has one, so flag.Value types such as configinator.ConnectionURL
+give the text they were set from.
+*/
+func valueString(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return value.String()
+	}
+
+	if value.CanAddr() {
+		if stringer, ok := value.Addr().Interface().(fmt.Stringer); ok {
+			return stringer.String()
+		}
+	}
+
+	return fmt.Sprint(value.Interface())
+}
+
+func (c *Container) allowsScheme(scheme string) bool {
+	for _, allowed := range c.schemes {
+		if scheme == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+parseHostname checks the hostname tag, which is true to check the
+syntax of hostnames, or resolve to look them up in DNS as well.
+*/
+func (c *Container) parseHostname(hostname string) error {
+	if hostname == "" {
+		return nil
+	}
+
+	if c.elementType().Kind() != reflect.String {
+		return fmt.Errorf("%w: %s is only for strings", ErrInvalid, TagHostname)
+	}
+
+	if hostname != HostnameSyntax && hostname != HostnameResolve {
+		return fmt.Errorf("%w: %s must be %s or %s, not %q", ErrInvalid, TagHostname, HostnameSyntax, HostnameResolve, hostname)
+	}
+
+	c.hostname = hostname
+	return nil
+}
+
+/*
+checkHostname returns ErrInvalid when a value isn't a valid hostname
+or IP address, optionally followed by a port. With a hostname tag of
+resolve, the hostname must also be found in DNS within
+DefaultLookupTimeout.
+*/
+func (c *Container) checkHostname(ctx context.Context) error {
+	if c.hostname == "" {
+		return nil
+	}
+
+	for _, value := range c.elements() {
+		host := value.String()
+
+		if host == "" {
+			continue
+		}
+
+		if withoutPort, _, err := net.SplitHostPort(host); err == nil {
+			host = withoutPort
+		}
+
+		if net.ParseIP(host) != nil {
+			continue
+		}
+
+		if !isHostname(host) {
+			return fmt.Errorf("%w: %q is not a valid hostname", ErrInvalid, host)
+		}
+
+		if c.hostname != HostnameResolve {
+			continue
+		}
+
+		lookupCtx, cancel := context.WithTimeout(ctx, DefaultLookupTimeout)
+		_, err := net.DefaultResolver.LookupHost(lookupCtx, host)
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalid, err.Error())
+		}
+	}
+
+	return nil
+}
+
+/*
+isHostname returns true for hostnames as described in RFC 1123: labels
+of letters, digits, and hyphens, separated by dots, where no label
+starts or ends with a hyphen. A trailing dot is allowed.
+*/
+func isHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+
+	if host == "" || len(host) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+/*
+compilePattern compiles the pattern tag. Patterns are only for strings
+and slices of strings.
+*/
+func (c *Container) compilePattern(pattern string) error {
+	var (
+		err error
+	)
+
+	if pattern == "" {
+		return nil
+	}
+
+	if c.elementType().Kind() != reflect.String {
+		return fmt.Errorf("%w: %s is only for strings", ErrInvalid, TagPattern)
+	}
+
+	if c.pattern, err = regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalid, TagPattern, err.Error())
+	}
+
+	return nil
+}
+
+/*
+checkPattern returns ErrInvalid when the value doesn't match the
+pattern tag. Each element of a slice must match.
+*/
+func (c *Container) checkPattern() error {
+	if c.pattern == nil {
+		return nil
+	}
+
+	for _, value := range c.elements() {
+		if !c.pattern.MatchString(value.String()) {
+			return fmt.Errorf("%w: %q does not match %s", ErrInvalid, value.String(), c.pattern)
+		}
+	}
+
+	return nil
+}
+
+/*
+parseOneOf splits the oneof tag into the values allowed, making sure
+each converts to the field's type. For slices, each element must be one
+of the values.
+*/
+func (c *Container) parseOneOf(oneOf string) error {
+	if oneOf == "" {
+		return nil
+	}
+
+	if c.IsMap() {
+		return fmt.Errorf("%w: %s is not for maps", ErrInvalid, TagOneOf)
+	}
+
+	for _, value := range strings.Split(oneOf, ",") {
+		value = strings.TrimSpace(value)
+
+		if _, err := c.convertElement(c.elementType(), value); err != nil {
+			return err
+		}
+
+		c.oneOf = append(c.oneOf, value)
+	}
+
+	return nil
+}
+
+/*
+checkOneOf returns ErrInvalid when the value isn't one of the values
+in the oneof tag.
+*/
+func (c *Container) checkOneOf() error {
+	if len(c.oneOf) == 0 {
+		return nil
+	}
+
+	for _, value := range c.elements() {
+		if !c.isOneOf(value) {
+			return fmt.Errorf("%w: %q is not one of %s", ErrInvalid, fmt.Sprint(value.Interface()), strings.Join(c.oneOf, ", "))
+		}
+	}
+
+	return nil
+}
+
+func (c *Container) isOneOf(value reflect.Value) bool {
+	for _, allowed := range c.oneOf {
+		converted, _ := c.convertElement(c.elementType(), allowed)
+
+		if reflect.DeepEqual(value.Interface(), converted.Interface()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+elementType returns the type of each value of a slice field, or the
+field's own type for everything else.
+*/
+func (c *Container) elementType() reflect.Type {
+	if c.valueType.Kind() == reflect.Slice && !c.IsBytes() && !c.IsDecoded() {
+		return c.valueType.Elem()
+	}
+
+	return c.valueType
+}
+
+/*
+elements returns each value of a slice field, or the field's value for
+everything else.
+*/
+func (c *Container) elements() []reflect.Value {
+	if c.value.Kind() != reflect.Slice || c.IsBytes() || c.IsDecoded() {
+		return []reflect.Value{c.value}
+	}
+
+	result := make([]reflect.Value, 0, c.value.Len())
+
+	for index := 0; index < c.value.Len(); index++ {
+		result = append(result, c.value.Index(index))
+	}
+
+	return result
+}
+
+/*
+Required returns true if the field must be given a value. That is when
+it has a required tag, or a required_if or required_with tag whose
+condition holds for the values of the other fields in its struct, so
+call it once values are loaded. For a condition, the tag is returned
+too, to say why the field is required.
+*/
+func (c *Container) Required() (bool, string) {
+	if c.required {
+		return true, ""
+	}
+
+	if len(c.requiredIf) > 0 && c.requiredIfHolds() {
+		return true, fmt.Sprintf("%s:%q", TagRequiredIf, c.files.Tag(c.field, TagRequiredIf))
+	}
+
+	for _, name := range c.requiredWith {
+		if !c.configValue.FieldByName(name).IsZero() {
+			return true, fmt.Sprintf("%s:%q", TagRequiredWith, name)
+		}
+	}
+
+	return false, ""
+}
+
+/*
+parseRequiredTags parses the required_if tag, written as Field=value
+pairs separated by commas, and the required_with tag, a list of field
+names. The names are of fields in the same struct.
+*/
+func (c *Container) parseRequiredTags(requiredIf, requiredWith string) error {
+	if requiredIf != "" {
+		c.requiredIf = make(map[string]string)
+
+		for _, condition := range strings.Split(requiredIf, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(condition), "=")
+
+			if !ok {
+				return fmt.Errorf("%w: %s must be written as Field=value, not %q", ErrInvalid, TagRequiredIf, condition)
+			}
+
+			c.requiredIf[name] = value
+		}
+	}
+
+	if requiredWith != "" {
+		for _, name := range strings.Split(requiredWith, ",") {
+			c.requiredWith = append(c.requiredWith, strings.TrimSpace(name))
+		}
+	}
+
+	for _, name := range append(c.requiredWith, mapKeys(c.requiredIf)...) {
+		if _, ok := c.configValue.Type().FieldByName(name); !ok {
+			return fmt.Errorf("%w: %s has no field named %s", ErrInvalid, c.configValue.Type(), name)
+		}
+	}
+
+	return nil
+}
+
+/*
+requiredIfHolds returns true when every field named in the required_if
+tag has the value given for it. Values are compared as they print, so
+true, 8080, and 5s match bool, int, and duration fields.
+*/
+func (c *Container) requiredIfHolds() bool {
+	for name, value := range c.requiredIf {
+		field := c.configValue.FieldByName(name)
+
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return false
+			}
+
+			field = field.Elem()
+		}
+
+		if fmt.Sprint(field.Interface()) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mapKeys(m map[string]string) []string {
+	result := make([]string, 0, len(m))
+
+	for key := range m {
+		result = append(result, key)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+/*
+compareNumbers returns -1, 0, or 1 as a is less than, equal to, or
+greater than b. Both must be the same numeric kind.
+*/
+func compareNumbers(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compare(a.Int() < b.Int(), a.Int() > b.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compare(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		return compare(a.Float() < b.Float(), a.Float() > b.Float())
+	}
+
+	return 0
+}
+
+func compare(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+
+	case greater:
+		return 1
+	}
+
+	return 0
+}
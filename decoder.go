@@ -0,0 +1,39 @@
+package configinator
+
+import (
+	"reflect"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+RegisterDecoder teaches the Configinator how to convert values into
+your own types. The decoder is given the raw value from a default,
+environment variable, .env file, or flag, and must return a value of
+type t. Register decoders before calling Behold. For example:
+
+	configinator.RegisterDecoder(reflect.TypeOf(net.IP{}), func(value string) (interface{}, error) {
+		if ip := net.ParseIP(value); ip != nil {
+			return ip, nil
+		}
+
+		return nil, fmt.Errorf("invalid IP address")
+	})
+
+Decoders take precedence over the built in conversions, and also apply
+to slices of t.
+*/
+func RegisterDecoder(t reflect.Type, decoder func(value string) (interface{}, error)) {
+	container.RegisterDecoder(t, decoder)
+}
+
+/*
+RegisterUUID parses a 16 byte array type as a UUID, such as
+"6ba7b810-9dad-11d1-80b4-00c04fd430c8". Types with UUID in their name,
+such as uuid.UUID, are parsed this way without registering them.
+
+	configinator.RegisterUUID(reflect.TypeOf(RequestID{}))
+*/
+func RegisterUUID(t reflect.Type) {
+	container.RegisterUUID(t)
+}
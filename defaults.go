@@ -0,0 +1,44 @@
+package configinator
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/app-nerds/configinator/configfile"
+	"github.com/app-nerds/configinator/container"
+	"github.com/app-nerds/configinator/env"
+)
+
+/*
+applyDefaults reads the .env file and first config file found in an
+embedded file system. Values from the .env file replace the default
+tag, and the config file is placed beneath the one read from disk, so
+anything on disk or in the environment overrides them.
+*/
+func applyDefaults(fsys fs.FS, files *container.Files, keys fileKeys) error {
+	var (
+		err    error
+		config configfile.Values
+	)
+
+	if files.DefaultEnv, err = env.ReadFS(fsys, ".env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for _, fileName := range configFileNames {
+		if config, err = configfile.ReadFS(fsys, fileName); err == nil {
+			if config, err = keys.rename(config); err != nil {
+				return err
+			}
+
+			files.Config = configfile.Merge(config, files.Config)
+			return nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package configinator
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+WithWarnings sets where warnings are written, such as when a deprecated
+field is used. Warnings go to stderr by default. Use io.Discard to
+silence them.
+*/
+func WithWarnings(w io.Writer) Option {
+	return func(o *options) {
+		o.warnings = w
+	}
+}
+
+/*
+warnDeprecated writes a warning for each field with a deprecated tag
+that was given a value, naming the flag or env variable used.
+*/
+func warnDeprecated(w io.Writer, containers []*container.Container) {
+	for _, c := range containers {
+		source := c.Source()
+
+		if c.Deprecated() == "" || source == "" || source == container.SourceDefault {
+			continue
+		}
+
+		fmt.Fprintf(w, "warning: %s is deprecated: %s\n", deprecatedName(c, source), c.Deprecated())
+	}
+}
+
+/*
+deprecatedName names a field the way it was given a value, such as
+-old-name for a flag or OLD_NAME for an env variable.
+*/
+func deprecatedName(c *container.Container, source string) string {
+	switch {
+	case source == container.SourceFlag && c.FlagName() != "":
+		return "-" + c.FlagName()
+
+	case source == container.SourceEnv || source == container.SourceEnvFile || source == container.SourceEnvRef:
+		if c.EnvName() != "" {
+			return c.EnvName()
+		}
+	}
+
+	return c.FieldName()
+}
@@ -0,0 +1,234 @@
+package configinator
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/app-nerds/configinator/configfile"
+	"github.com/app-nerds/configinator/env"
+)
+
+// Extensions of encrypted env files
+const (
+	ageExtension   string = ".age"
+	pgpExtension   string = ".gpg"
+	vaultExtension string = ".vault"
+)
+
+/*
+DotenvKeyVariable is the environment variable holding the key to a
+.env.vault file.
+*/
+const DotenvKeyVariable string = "DOTENV_KEY"
+
+/*
+WithAgeIdentity sets the age identity file used to decrypt env files
+encrypted with age (https://age-encryption.org), such as .env.age.
+*/
+func WithAgeIdentity(fileName string) Option {
+	return func(o *options) {
+		o.ageIdentity = fileName
+	}
+}
+
+/*
+readEnvFile reads an env file, decrypting it first if it is encrypted.
+Files ending in .age are decrypted with the age tool, using the age
+identity. Files ending in .gpg or .asc are decrypted with gpg, which
+finds the key itself. Files ending in .vault are dotenv-vault files,
+decrypted with the DOTENV_KEY. The plaintext is only held in memory.
+*/
+func readEnvFile(ctx context.Context, fileName string, ageIdentity string, dotenvKey string) (map[string]string, error) {
+	var (
+		err    error
+		values map[string]string
+		output []byte
+	)
+
+	switch {
+	case strings.HasSuffix(fileName, vaultExtension):
+		return env.ReadVault(fileName, dotenvKey)
+
+	case strings.HasSuffix(fileName, ageExtension):
+		if ageIdentity == "" {
+			return nil, fmt.Errorf("an age identity is needed to decrypt %s. Use WithAgeIdentity", fileName)
+		}
+
+		output, err = configfile.RunToolContext(ctx, "age", "--decrypt", "--identity", ageIdentity, fileName)
+
+	case strings.HasSuffix(fileName, pgpExtension), strings.HasSuffix(fileName, ".asc"):
+		output, err = configfile.RunToolContext(ctx, "gpg", "--decrypt", "--batch", "--quiet", fileName)
+
+	default:
+		if values, err = env.ReadFile(fileName); err != nil {
+			return nil, err
+		}
+
+		if _, ok := values[sopsMACKey]; ok {
+			return readSOPSEnvFile(ctx, fileName)
+		}
+
+		return values, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting %s: %w", fileName, err)
+	}
+
+	return env.Parse(bytes.NewReader(output))
+}
+
+/*
+EncryptedPrefix marks a value as encrypted, as in "enc:<ciphertext>".
+*/
+const EncryptedPrefix string = "enc:"
+
+/*
+Decrypter decrypts the ciphertext of a value written as
+"enc:<ciphertext>". The context is the one given to BeholdContext, so
+calls to a KMS or secret manager can be cancelled.
+*/
+type Decrypter func(ctx context.Context, ciphertext string) (string, error)
+
+/*
+WithDecrypter decrypts values from any source written as
+"enc:<ciphertext>", so only sensitive values need encrypting rather
+than whole files. Use it to plug in a KMS or secret manager's SDK.
+*/
+func WithDecrypter(decrypter Decrypter) Option {
+	return func(o *options) {
+		o.decrypter = decrypter
+	}
+}
+
+/*
+WithEncryptionKey decrypts values written as "enc:<ciphertext>" with a
+32 byte AES-256-GCM key. Encrypt values with EncryptValue.
+*/
+func WithEncryptionKey(key []byte) Option {
+	return WithDecrypter(func(ctx context.Context, ciphertext string) (string, error) {
+		return decryptAESGCM(key, ciphertext)
+	})
+}
+
+/*
+WithKMS decrypts values written as "enc:<ciphertext>" with AWS KMS,
+where the ciphertext is the base64 encoded blob from "aws kms encrypt".
+Values are decrypted with the aws command line tool, which must be in
+your PATH, so credentials come from the standard AWS chain. The tool is
+killed when the context is cancelled.
+*/
+func WithKMS() Option {
+	return WithDecrypter(func(ctx context.Context, ciphertext string) (string, error) {
+		var (
+			err       error
+			output    []byte
+			plaintext []byte
+		)
+
+		if output, err = configfile.RunToolContext(ctx, "aws", "kms", "decrypt", "--ciphertext-blob", ciphertext, "--output", "text", "--query", "Plaintext"); err != nil {
+			return "", err
+		}
+
+		if plaintext, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(output))); err != nil {
+			return "", err
+		}
+
+		return string(plaintext), nil
+	})
+}
+
+/*
+EncryptValue encrypts a value with a 32 byte AES-256-GCM key, returning
+it as "enc:<ciphertext>" ready to be placed in any source. The
+ciphertext is the base64 encoded nonce followed by the sealed value.
+*/
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	var (
+		err   error
+		block cipher.Block
+		gcm   cipher.AEAD
+	)
+
+	if block, err = aes.NewCipher(key); err != nil {
+		return "", err
+	}
+
+	if gcm, err = cipher.NewGCM(block); err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptAESGCM(key []byte, ciphertext string) (string, error) {
+	var (
+		err       error
+		data      []byte
+		block     cipher.Block
+		gcm       cipher.AEAD
+		plaintext []byte
+	)
+
+	if data, err = base64.StdEncoding.DecodeString(ciphertext); err != nil {
+		return "", err
+	}
+
+	if block, err = aes.NewCipher(key); err != nil {
+		return "", err
+	}
+
+	if gcm, err = cipher.NewGCM(block); err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	if plaintext, err = gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil); err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+/*
+decryptValue decrypts a value written as "enc:<ciphertext>". Other
+values are returned as is.
+*/
+func decryptValue(ctx context.Context, value string, decrypter Decrypter) (string, error) {
+	var (
+		err    error
+		result string
+	)
+
+	ciphertext, ok := strings.CutPrefix(value, EncryptedPrefix)
+
+	if !ok {
+		return value, nil
+	}
+
+	if decrypter == nil {
+		return "", fmt.Errorf("the value is encrypted, but no key was given. Use WithEncryptionKey or WithDecrypter")
+	}
+
+	if result, err = decrypter(ctx, ciphertext); err != nil {
+		return "", fmt.Errorf("error decrypting value: %w", err)
+	}
+
+	return result, nil
+}
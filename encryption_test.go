@@ -0,0 +1,175 @@
+package configinator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEncryptedValues(t *testing.T) {
+	type config struct {
+		Password string `flag:"password" env:"PASSWORD"`
+		Count    int    `flag:"count" env:"COUNT"`
+		Listen   Port   `flag:"listen" env:"LISTEN"`
+	}
+
+	key := bytes.Repeat([]byte{7}, 32)
+	otherKey := bytes.Repeat([]byte{8}, 32)
+
+	encrypt := func(plaintext string) string {
+		value, err := EncryptValue(key, plaintext)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return value
+	}
+
+	password := encrypt("hunter2")
+	count := encrypt("3")
+	listen := encrypt("8080")
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		options []Option
+		want    config
+		err     bool
+	}{
+		{name: "string flag", args: []string{"-password", password}, options: []Option{WithEncryptionKey(key)}, want: config{Password: "hunter2"}},
+		{name: "int flag", args: []string{"-count", count}, options: []Option{WithEncryptionKey(key)}, want: config{Count: 3}},
+		{name: "flag.Value flag", args: []string{"-listen", listen}, options: []Option{WithEncryptionKey(key)}, want: config{Listen: 8080}},
+		{name: "env", env: map[string]string{"PASSWORD": password, "LISTEN": listen}, options: []Option{WithEncryptionKey(key)}, want: config{Password: "hunter2", Listen: 8080}},
+		{name: "plain value", args: []string{"-password", "plain"}, options: []Option{WithEncryptionKey(key)}, want: config{Password: "plain"}},
+		{name: "no key", args: []string{"-password", password}, err: true},
+		{name: "wrong key", args: []string{"-password", password}, options: []Option{WithEncryptionKey(otherKey)}, err: true},
+		{
+			name: "decrypter",
+			args: []string{"-password", "enc:secret"},
+			options: []Option{WithDecrypter(func(ctx context.Context, ciphertext string) (string, error) {
+				if ciphertext != "secret" {
+					return "", errors.New("unexpected ciphertext")
+				}
+
+				return "decrypted", nil
+			})},
+			want: config{Password: "decrypted"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			err := BeholdE(&got, append([]Option{WithFlagSet(flags), WithArgs(test.args)}, test.options...)...)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != test.want {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestEncryptedEnvFiles(t *testing.T) {
+	type config struct {
+		Host     string `flag:"host" env:"HOST"`
+		Password string `flag:"password" env:"PASSWORD"`
+		Token    string `flag:"token" env:"TOKEN"`
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in age and gpg tools are shell scripts")
+	}
+
+	/*
+	 * Stand-ins for age and gpg print the file they are given, so the
+	 * "encrypted" files hold plain text. age checks its identity
+	 */
+	tools := t.TempDir()
+	dir := t.TempDir()
+	identity := filepath.Join(dir, "key.txt")
+
+	write := func(path, contents string, mode os.FileMode) {
+		if err := os.WriteFile(path, []byte(contents), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(tools, "age"), "#!/bin/sh\n[ \"$3\" = \""+identity+"\" ] || { echo 'no identity' >&2; exit 1; }\ncat \"$4\"\n", 0700)
+	write(filepath.Join(tools, "gpg"), "#!/bin/sh\ncat \"$4\"\n", 0700)
+	t.Setenv("PATH", tools+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	envFile := filepath.Join(dir, ".env")
+	write(envFile, "HOST=plain\nPASSWORD=plain\nTOKEN=plain\n", 0600)
+	write(envFile+".age", "PASSWORD=from-age\nTOKEN=from-age\n", 0600)
+	write(envFile+".gpg", "TOKEN=from-gpg\n", 0600)
+
+	tests := []struct {
+		name    string
+		options []Option
+		want    config
+		err     bool
+	}{
+		{name: "age and gpg", options: []Option{WithAgeIdentity(identity)}, want: config{Host: "plain", Password: "from-age", Token: "from-gpg"}},
+		{name: "no age identity", err: true},
+		{name: "wrong age identity", options: []Option{WithAgeIdentity(filepath.Join(dir, "other.txt"))}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			err := BeholdE(&got, append([]Option{WithFlagSet(flags), WithArgs(nil), WithEnvFile(envFile)}, test.options...)...)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != test.want {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
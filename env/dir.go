@@ -0,0 +1,123 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+ReadDir reads an envdir style directory, as used by daemontools and
+runit, where each file is a variable named after the file. The value is
+the first line of the file, with trailing spaces and tabs removed, and
+NUL characters turned into newlines. An empty file gives an empty
+value, rather than unsetting the variable as envdir does.
+*/
+func ReadDir(dir string) (map[string]string, error) {
+	var (
+		err   error
+		files map[string][]byte
+	)
+
+	result := make(map[string]string)
+
+	if files, err = readDirFiles(dir); err != nil {
+		return result, err
+	}
+
+	for name, data := range files {
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		line = bytes.ReplaceAll(line, []byte{0}, []byte("\n"))
+		result[name] = strings.TrimRight(string(line), " \t")
+	}
+
+	return result, nil
+}
+
+/*
+ReadSecret reads one secret file from a directory of secret files, and
+returns true if it was read. Trailing newlines are removed. A file that
+doesn't exist, isn't a regular file, or can't be read, such as one
+owned by another user, returns false, as do names of hidden files or
+with a path in them.
+*/
+func ReadSecret(dir, name string) (string, bool) {
+	if name == "" || strings.HasPrefix(name, ".") || filepath.Base(name) != name {
+		return "", false
+	}
+
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return "", false
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true
+}
+
+/*
+DataDir returns the directory holding the files of a mounted directory.
+
+Kubernetes mounts ConfigMaps and Secrets as a directory of symlinks
+into ..data, which is itself a symlink kubelet swaps to update every
+key at once. When ..data is present, the directory it points to is
+returned, so all values come from the same update. Otherwise the
+directory is returned as is.
+*/
+func DataDir(dir string) string {
+	if target, err := filepath.EvalSymlinks(filepath.Join(dir, "..data")); err == nil {
+		return target
+	}
+
+	return dir
+}
+
+/*
+readDirFiles reads every regular file in a directory, following
+symlinks, and its ..data directory when there is one. See DataDir.
+Hidden files are skipped, as are files that can't be read, such as
+dangling symlinks or files owned by another user.
+*/
+func readDirFiles(dir string) (map[string][]byte, error) {
+	var (
+		err     error
+		entries []os.DirEntry
+		info    os.FileInfo
+		data    []byte
+	)
+
+	result := make(map[string][]byte)
+	dir = DataDir(dir)
+
+	if entries, err = os.ReadDir(dir); err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		if info, err = os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		if data, err = os.ReadFile(path); err != nil {
+			continue
+		}
+
+		result[entry.Name()] = data
+	}
+
+	return result, nil
+}
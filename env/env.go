@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"regexp"
 	"strings"
@@ -26,7 +27,11 @@ func isIgnoredLine(line string) bool {
 	return len(trimmed) == 0 || strings.HasPrefix(trimmed, "#")
 }
 
-func parse(r io.Reader) (map[string]string, error) {
+/*
+Parse reads env formatted data, such as the contents of an .env file,
+and returns a map of key/value pairs.
+*/
+func Parse(r io.Reader) (map[string]string, error) {
 	var (
 		err   error
 		lines []string
@@ -151,6 +156,13 @@ func parseValue(value string) string {
 	return value
 }
 
+/*
+ParseString reads env formatted data from a string.
+*/
+func ParseString(data string) (map[string]string, error) {
+	return Parse(strings.NewReader(data))
+}
+
 /*
 ReadFile reads an .env file and returns a map of key/value pairs.
 */
@@ -167,5 +179,25 @@ func ReadFile(fileName string) (map[string]string, error) {
 	}
 
 	defer f.Close()
-	return parse(f)
+	return Parse(f)
+}
+
+/*
+ReadFS reads an env file from a file system, such as one embedded with
+go:embed.
+*/
+func ReadFS(fsys fs.FS, fileName string) (map[string]string, error) {
+	var (
+		err error
+		f   fs.File
+	)
+
+	result := make(map[string]string)
+
+	if f, err = fsys.Open(fileName); err != nil {
+		return result, err
+	}
+
+	defer f.Close()
+	return Parse(f)
 }
@@ -0,0 +1,104 @@
+package env
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+/*
+ReadVault reads a .env.vault file, as made by dotenv-vault, and decrypts
+the environment named in the DOTENV_KEY. The key is a URI such as
+
+	dotenv://:key_1234...@dotenv.org/vault/.env.vault?environment=production
+
+Several keys may be given, separated by commas, and each is tried in
+turn, which allows keys to be rotated.
+*/
+func ReadVault(fileName string, dotenvKey string) (map[string]string, error) {
+	var (
+		err    error
+		vault  map[string]string
+		result map[string]string
+	)
+
+	if vault, err = ReadFile(fileName); err != nil {
+		return nil, err
+	}
+
+	for _, key := range strings.Split(dotenvKey, ",") {
+		if result, err = decryptVault(vault, strings.TrimSpace(key)); err == nil {
+			return result, nil
+		}
+	}
+
+	return nil, fmt.Errorf("error decrypting %s: %w", fileName, err)
+}
+
+func decryptVault(vault map[string]string, dotenvKey string) (map[string]string, error) {
+	var (
+		err        error
+		uri        *url.URL
+		key        []byte
+		ciphertext []byte
+		block      cipher.Block
+		gcm        cipher.AEAD
+		plaintext  []byte
+	)
+
+	if uri, err = url.Parse(dotenvKey); err != nil {
+		return nil, fmt.Errorf("invalid DOTENV_KEY: %w", err)
+	}
+
+	password, _ := uri.User.Password()
+	environment := uri.Query().Get("environment")
+
+	if password == "" || environment == "" {
+		return nil, fmt.Errorf("invalid DOTENV_KEY: it needs a key and an environment")
+	}
+
+	name := "DOTENV_VAULT_" + strings.ToUpper(environment)
+	encrypted, ok := vault[name]
+
+	if !ok {
+		return nil, fmt.Errorf("%s is not in the vault", name)
+	}
+
+	hexKey := strings.TrimPrefix(password, "key_")
+
+	if len(hexKey) > 64 {
+		hexKey = hexKey[len(hexKey)-64:]
+	}
+
+	if key, err = hex.DecodeString(hexKey); err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid DOTENV_KEY: the key must be 64 hex characters")
+	}
+
+	if ciphertext, err = base64.StdEncoding.DecodeString(encrypted); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	if block, err = aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+
+	if gcm, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid %s: too short", name)
+	}
+
+	nonce := ciphertext[:gcm.NonceSize()]
+
+	if plaintext, err = gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil); err != nil {
+		return nil, fmt.Errorf("the DOTENV_KEY can't decrypt %s", name)
+	}
+
+	return ParseString(string(plaintext))
+}
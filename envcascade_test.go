@@ -0,0 +1,71 @@
+package configinator
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvCascade(t *testing.T) {
+	type config struct {
+		Host  string `flag:"host" env:"HOST"`
+		Port  int    `flag:"port" env:"PORT"`
+		Debug bool   `flag:"debug" env:"DEBUG"`
+		Name  string `flag:"name" env:"NAME"`
+	}
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+
+	for name, contents := range map[string]string{
+		".env":                  "HOST=base\nPORT=80\nDEBUG=false\nNAME=base\n",
+		".env.production":       "HOST=production\nPORT=443\n",
+		".env.local":            "PORT=8080\nDEBUG=true\n",
+		".env.production.local": "NAME=production-local\n",
+		".env.test":             "HOST=test\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		options []Option
+		want    config
+	}{
+		{name: "no app env", want: config{Host: "base", Port: 80, Name: "base"}},
+		{name: "APP_ENV", env: map[string]string{AppEnvVariable: "production"}, want: config{Host: "production", Port: 8080, Debug: true, Name: "production-local"}},
+		{name: "WithAppEnv", options: []Option{WithAppEnv("test")}, want: config{Host: "test", Port: 8080, Debug: true, Name: "base"}},
+		{name: "WithAppEnv over APP_ENV", env: map[string]string{AppEnvVariable: "production"}, options: []Option{WithAppEnv("test")}, want: config{Host: "test", Port: 8080, Debug: true, Name: "base"}},
+		{name: "empty app env", options: []Option{WithAppEnv("")}, want: config{Host: "base", Port: 8080, Debug: true, Name: "base"}},
+		{name: "flag wins", env: map[string]string{AppEnvVariable: "production"}, args: []string{"-host", "from-flag"}, want: config{Host: "from-flag", Port: 8080, Debug: true, Name: "production-local"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			if err := BeholdE(&got, append([]Option{WithFlagSet(flags), WithArgs(test.args), WithEnvFile(envFile)}, test.options...)...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != test.want {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package configinator
+
+import (
+	"fmt"
+)
+
+/*
+FieldError is returned by BeholdE when a field can't be configured,
+such as when its default doesn't convert to the field's type, a source
+gives it an invalid value, or it has a flag tag but isn't exported.
+Source is the source of the value, such as "env", when there is one.
+Use errors.Is with the container errors, such as container.ErrInvalid,
+to tell what went wrong.
+*/
+type FieldError struct {
+	Field  string
+	Flag   string
+	Source string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	name := e.Field
+
+	if e.Flag != "" {
+		name += " (-" + e.Flag + ")"
+	}
+
+	if e.Source != "" {
+		return fmt.Sprintf("%s: value from %s: %s", name, e.Source, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", name, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func newFieldError(c fieldNamer, source string, err error) error {
+	return &FieldError{
+		Field:  c.FieldName(),
+		Flag:   c.FlagName(),
+		Source: source,
+		Err:    err,
+	}
+}
+
+type fieldNamer interface {
+	FieldName() string
+	FlagName() string
+}
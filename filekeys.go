@@ -0,0 +1,92 @@
+package configinator
+
+import (
+	"flag"
+	"io"
+	"reflect"
+
+	"github.com/app-nerds/configinator/configfile"
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+fileKeys renames the keys of config files to flag names as they are
+read. A field is found in a file under the name from the tag for the
+file's format, such as yaml, so files of different formats can only be
+merged once they share the same keys.
+*/
+type fileKeys struct {
+	config reflect.Value
+	files  container.Files
+	prefix string
+}
+
+/*
+rename returns the values keyed by the flag names of the config struct
+and its subcommands. The fields are set up on a copy of the struct, so
+struct slices are grown to fit the values.
+*/
+func (k fileKeys) rename(values configfile.Values) (configfile.Values, error) {
+	var (
+		err        error
+		containers []*container.Container
+	)
+
+	if !k.config.IsValid() || values.Format == "" {
+		return values, nil
+	}
+
+	files := k.files
+	files.Config = values
+
+	if containers, err = commandContainers(reflect.New(k.config.Type()).Elem(), k.prefix, files); err != nil {
+		return values, err
+	}
+
+	keys := make(map[string]string, len(containers))
+
+	for _, c := range containers {
+		if key := c.FileKey(""); key != "" {
+			keys[key] = c.FileKey(values.Format)
+		}
+	}
+
+	return values.Rename(keys), nil
+}
+
+/*
+commandContainers sets up the fields of a config struct, and of each
+subcommand struct beneath it. Each struct adds its flags to a flag set
+of its own, as subcommands do.
+*/
+func commandContainers(config reflect.Value, prefix string, files container.Files) ([]*container.Container, error) {
+	var (
+		err        error
+		nested     []*container.Container
+		containers []*container.Container
+	)
+
+	files.Flags = flag.NewFlagSet("", flag.ContinueOnError)
+	files.Flags.SetOutput(io.Discard)
+
+	if containers, err = setupContainers(config, prefix, files); err != nil {
+		return containers, err
+	}
+
+	for _, index := range commandFields(config, files) {
+		field := config.Type().Field(index)
+		fieldType := field.Type
+
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if nested, err = commandContainers(reflect.New(fieldType).Elem(), joinPrefix(prefix, files.Tag(field, container.TagPrefix)), files); err != nil {
+			return containers, err
+		}
+
+		containers = append(containers, nested...)
+	}
+
+	return containers, nil
+}
@@ -0,0 +1,54 @@
+package configinator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+/*
+FileValuePrefix starts a value naming a file to read the value from,
+when WithFileValues is used.
+*/
+const FileValuePrefix string = "@"
+
+/*
+WithFileValues reads a value starting with @ from the file it names,
+so large values, such as PEM blocks and tokens, can be kept in files.
+With it, -tls-cert @/etc/certs/cert.pem reads the certificate from that
+file, and the same goes for env variables and other sources. Trailing
+newlines are removed. Start a value with @@ for a value that really
+starts with @.
+*/
+func WithFileValues() Option {
+	return func(o *options) {
+		o.fileValues = true
+	}
+}
+
+/*
+readFileValue returns the contents of the file named by a value that
+starts with @. Other values are returned as they are.
+*/
+func readFileValue(value string) (string, error) {
+	var (
+		err  error
+		data []byte
+	)
+
+	fileName, ok := strings.CutPrefix(value, FileValuePrefix)
+
+	if !ok {
+		return value, nil
+	}
+
+	if strings.HasPrefix(fileName, FileValuePrefix) {
+		return fileName, nil
+	}
+
+	if data, err = os.ReadFile(fileName); err != nil {
+		return "", fmt.Errorf("error reading value from file: %w", err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,71 @@
+package configinator
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileValueFlags(t *testing.T) {
+	type config struct {
+		Count  int           `flag:"count" env:"COUNT"`
+		Listen Port          `flag:"listen" env:"LISTEN"`
+		DB     ConnectionURL `flag:"db" env:"DB"`
+		Name   string        `flag:"name"`
+	}
+
+	dir := t.TempDir()
+
+	write := func(name, value string) string {
+		path := filepath.Join(dir, name)
+
+		if err := os.WriteFile(path, []byte(value+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		return "@" + path
+	}
+
+	count := write("count", "3")
+	listen := write("listen", "8080")
+	db := write("db", "postgres://user:pass@db:5432/app")
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want func(c config) bool
+	}{
+		{name: "int flag", args: []string{"-count", count}, want: func(c config) bool { return c.Count == 3 }},
+		{name: "flag.Value flag", args: []string{"-listen", listen}, want: func(c config) bool { return c.Listen == 8080 }},
+		{name: "connection URL flag", args: []string{"-db", db}, want: func(c config) bool { return c.DB.Host == "db" && c.DB.Port == "5432" }},
+		{name: "escaped @", args: []string{"-name", "@@me"}, want: func(c config) bool { return c.Name == "@me" }},
+		{name: "env", env: map[string]string{"COUNT": count, "LISTEN": listen}, want: func(c config) bool { return c.Count == 3 && c.Listen == 8080 }},
+		{name: "flag over env", args: []string{"-listen", "9090"}, env: map[string]string{"LISTEN": listen}, want: func(c config) bool { return c.Listen == 9090 }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			if err := BeholdE(&got, WithFlagSet(flags), WithArgs(test.args), WithFileValues()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.want(got) {
+				t.Errorf("unexpected config %+v", got)
+			}
+		})
+	}
+}
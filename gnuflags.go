@@ -0,0 +1,117 @@
+package configinator
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+/*
+WithGNUFlags parses the command line the way GNU and POSIX tools do.
+Long flags are given with two dashes, as --host or --host=localhost,
+and a single dash starts one or more single letter flags, so -v, -vq,
+-p 8080, and -p8080 all work. Flags and arguments may be mixed, and --
+ends the flags. Any flag named with a single letter can be used this
+way. Arguments that aren't flags are left in the flag set's Args.
+*/
+func WithGNUFlags() Option {
+	return func(o *options) {
+		o.gnuFlags = true
+	}
+}
+
+/*
+gnuArgs rewrites a GNU style command line into one the flag package
+parses. Letter groups, such as -vp8080, are split into separate flags,
+and arguments that aren't flags are moved after a -- so flags that
+follow them are still parsed. With subcommands, the first argument that
+isn't a flag ends the flags, as it names the command and the rest are
+its own.
+
+The command line is rewritten rather than parsed with a package such as
+pflag, so every field still has one flag in the standard flag set. Flag
+sets passed to WithFlagSet, flag.Value fields, help, and subcommands
+work the same in both styles, and the module needs no flag dependency.
+*/
+func gnuArgs(flags *flag.FlagSet, args []string, hasCommands bool) ([]string, error) {
+	var (
+		result     []string
+		positional []string
+	)
+
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+
+		switch {
+		case arg == "--":
+			positional = append(positional, args[index+1:]...)
+			index = len(args)
+
+		case strings.HasPrefix(arg, "--"):
+			result = append(result, arg)
+			name := strings.TrimPrefix(arg, "--")
+
+			if strings.Contains(name, "=") || isBoolFlag(flags, name) || flags.Lookup(name) == nil {
+				continue
+			}
+
+			if index+1 < len(args) {
+				index++
+				result = append(result, args[index])
+			}
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			letters := []rune(strings.TrimPrefix(arg, "-"))
+
+			for position, letter := range letters {
+				name := string(letter)
+
+				if flags.Lookup(name) == nil {
+					return nil, fmt.Errorf("unknown flag '%s' in %s", name, arg)
+				}
+
+				if isBoolFlag(flags, name) {
+					result = append(result, "-"+name)
+					continue
+				}
+
+				if rest := string(letters[position+1:]); rest != "" {
+					result = append(result, "-"+name+"="+strings.TrimPrefix(rest, "="))
+					break
+				}
+
+				if index+1 >= len(args) {
+					return nil, fmt.Errorf("flag needs an argument: -%s", name)
+				}
+
+				index++
+				result = append(result, "-"+name, args[index])
+			}
+
+		case hasCommands:
+			positional = append(positional, args[index:]...)
+			index = len(args)
+
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) > 0 {
+		result = append(result, "--")
+		result = append(result, positional...)
+	}
+
+	return result, nil
+}
+
+func isBoolFlag(flags *flag.FlagSet, name string) bool {
+	f := flags.Lookup(name)
+
+	if f == nil {
+		return false
+	}
+
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
@@ -0,0 +1,116 @@
+package configinator
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestGNUArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		hasCommands bool
+		want        []string
+		err         bool
+		parseErr    bool
+	}{
+		{name: "empty", args: []string{}, want: nil},
+		{name: "long flag", args: []string{"--host", "localhost"}, want: []string{"--host", "localhost"}},
+		{name: "long flag with equals", args: []string{"--host=localhost"}, want: []string{"--host=localhost"}},
+		{name: "long bool flag", args: []string{"--verbose", "file"}, want: []string{"--verbose", "--", "file"}},
+		{name: "unknown long flag", args: []string{"--nope", "file"}, want: []string{"--nope", "--", "file"}, parseErr: true},
+		{name: "short flag", args: []string{"-p", "8080"}, want: []string{"-p", "8080"}},
+		{name: "short flag joined", args: []string{"-p8080"}, want: []string{"-p=8080"}},
+		{name: "short flag with equals", args: []string{"-p=8080"}, want: []string{"-p=8080"}},
+		{name: "bool group", args: []string{"-vq"}, want: []string{"-v", "-q"}},
+		{name: "bool group then value", args: []string{"-vp8080"}, want: []string{"-v", "-p=8080"}},
+		{name: "bool group then separate value", args: []string{"-vp", "8080"}, want: []string{"-v", "-p", "8080"}},
+		{name: "arguments between flags", args: []string{"a", "-v", "b", "--host", "h"}, want: []string{"-v", "--host", "h", "--", "a", "b"}},
+		{name: "double dash ends flags", args: []string{"-v", "--", "-q", "a"}, want: []string{"-v", "--", "-q", "a"}},
+		{name: "single dash is an argument", args: []string{"-", "-v"}, want: []string{"-v", "--", "-"}},
+		{name: "command ends flags", args: []string{"-v", "run", "-q"}, hasCommands: true, want: []string{"-v", "--", "run", "-q"}},
+		{name: "unknown short flag", args: []string{"-vx"}, err: true},
+		{name: "missing value", args: []string{"-p"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+			flags.Bool("v", false, "")
+			flags.Bool("q", false, "")
+			flags.Bool("verbose", false, "")
+			flags.Int("p", 0, "")
+			flags.String("host", "", "")
+
+			got, err := gnuArgs(flags, test.args, test.hasCommands)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+
+			/*
+			 * Unknown long flags are left for the flag package to report
+			 */
+			if err = flags.Parse(got); (err != nil) != test.parseErr {
+				t.Errorf("parsing %q: unexpected result %v", got, err)
+			}
+		})
+	}
+}
+
+func TestWithGNUFlags(t *testing.T) {
+	type config struct {
+		Host    string `flag:"host"`
+		Port    int    `flag:"port" short:"p"`
+		Verbose int    `flag:"verbose" short:"v" count:"true"`
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want config
+		rest []string
+	}{
+		{name: "long flags", args: []string{"--host", "h", "--port=80"}, want: config{Host: "h", Port: 80}},
+		{name: "short flags", args: []string{"-p8080", "-vvv"}, want: config{Port: 8080, Verbose: 3}},
+		{name: "mixed arguments", args: []string{"a", "-v", "b", "--host=h"}, want: config{Host: "h", Verbose: 1}, rest: []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+
+			if err := BeholdE(&got, WithFlagSet(flags), WithArgs(test.args), WithGNUFlags()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != test.want {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+
+			if rest := flags.Args(); len(rest) != len(test.rest) || (len(rest) > 0 && !reflect.DeepEqual(rest, test.rest)) {
+				t.Errorf("expected arguments %q, got %q", test.rest, rest)
+			}
+		})
+	}
+}
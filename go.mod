@@ -1,8 +1,8 @@
 module github.com/app-nerds/configinator
 
-go 1.16
+go 1.21
 
 require (
-	github.com/spf13/pflag v1.0.5
-	github.com/spf13/viper v1.9.0
+	github.com/hashicorp/hcl v1.0.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,358 @@
+package configinator
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+helpRow is a line of help output: how an option is given on the command
+line, its env variables, its default, and what it does.
+*/
+type helpRow struct {
+	group       string
+	flags       string
+	env         string
+	defaultText string
+	description string
+}
+
+/*
+WithoutColor turns off colored help. Help is colored only when it is
+written to a terminal, and never when the NO_COLOR environment variable
+is set, so this is for programs that want plain help regardless.
+*/
+func WithoutColor() Option {
+	return func(o *options) {
+		o.noColor = true
+	}
+}
+
+/*
+helpStyle is how help is laid out: the width descriptions are wrapped
+to and whether flags, defaults, and headings are colored.
+*/
+type helpStyle struct {
+	width int
+	color bool
+}
+
+const (
+	colorBold   = "\033[1m"
+	colorCyan   = "\033[36m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+var (
+	headerColors = [4]string{colorBold, colorBold, colorBold, colorBold}
+	rowColors    = [4]string{colorCyan, colorGreen, colorYellow, ""}
+)
+
+/*
+setUsage replaces the flag set's usage with help generated from the
+config struct, so the flags, env variables, and defaults of every option
+are shown together. With GNU flags, long flags are shown with two
+dashes.
+*/
+func setUsage(flags *flag.FlagSet, containers []*container.Container, commands map[string]int, gnuFlags, noColor bool) {
+	dashes := "-"
+
+	if gnuFlags {
+		dashes = "--"
+	}
+
+	flags.Usage = func() {
+		w := flags.Output()
+
+		style := helpStyle{
+			width: terminalWidth(w),
+			color: !noColor && useColor(w),
+		}
+
+		printUsage(w, flags, containers, commands, dashes, style)
+	}
+}
+
+/*
+printUsage writes the help for a flag set. Fields with a flag are
+listed in the order they are declared, followed by flags that don't
+belong to a field, such as -config. Fields with a group tag are listed
+after the others under a heading for the group, with groups in the
+order they are first used.
+*/
+func printUsage(w io.Writer, flags *flag.FlagSet, containers []*container.Container, commands map[string]int, dashes string, style helpStyle) {
+	var (
+		rows []helpRow
+	)
+
+	owned := make(map[string]bool)
+
+	for _, c := range containers {
+		usage := c.Usage()
+
+		/*
+		 * Only fields with a flag in the flag set are listed, leaving out
+		 * rest fields, and fields only set from the environment
+		 */
+		if c.IsRest() || usage.Flag == "" || flags.Lookup(usage.Flag) == nil {
+			continue
+		}
+
+		rows = append(rows, usageRow(usage, dashes))
+
+		for _, name := range append([]string{usage.Flag, usage.Short, usage.Negated}, usage.Aliases...) {
+			owned[name] = true
+		}
+	}
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if owned[f.Name] {
+			return
+		}
+
+		name, usage := flag.UnquoteUsage(f)
+		defaultText := f.DefValue
+
+		if defaultText == "false" {
+			defaultText = ""
+		}
+
+		rows = append(rows, helpRow{
+			flags:       strings.TrimSpace(dashes + f.Name + " " + name),
+			defaultText: defaultText,
+			description: usage,
+		})
+	})
+
+	usageLine := "Usage: " + flags.Name() + " [flags]"
+
+	if len(commands) > 0 {
+		usageLine += " <command> [command flags]"
+	}
+
+	fmt.Fprintf(w, "%s\n\n", style.paint(usageLine, colorBold))
+
+	if len(commands) > 0 {
+		names := make([]string, 0, len(commands))
+
+		for name := range commands {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		fmt.Fprintf(w, "%s\n", style.paint("Commands:", colorBold))
+
+		for _, name := range names {
+			fmt.Fprintf(w, "  %s\n", style.paint(name, colorCyan))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, style.paint("Options:", colorBold))
+	writeRows(w, rows, style)
+}
+
+/*
+usageRow builds the help line for a field.
+*/
+func usageRow(usage container.Usage, dashes string) helpRow {
+	var (
+		names []string
+	)
+
+	if usage.Short != "" {
+		names = append(names, "-"+usage.Short)
+	}
+
+	if usage.Flag != "" {
+		flagName := dashes + usage.Flag
+
+		if !usage.IsBool {
+			flagName += " " + usage.Type
+		}
+
+		names = append(names, flagName)
+	}
+
+	if usage.Negated != "" {
+		names = append(names, dashes+usage.Negated)
+	}
+
+	description := usage.Description
+
+	if len(usage.OneOf) > 0 {
+		description = strings.TrimSpace(description + " (one of: " + strings.Join(usage.OneOf, ", ") + ")")
+	}
+
+	if usage.Required {
+		description = strings.TrimSpace(description + " (required)")
+	}
+
+	if usage.Deprecated != "" {
+		description = strings.TrimSpace(description + " (deprecated: " + usage.Deprecated + ")")
+	}
+
+	if len(usage.Aliases) > 0 {
+		description = strings.TrimSpace(description + " (deprecated: " + dashes + strings.Join(usage.Aliases, ", "+dashes) + ")")
+	}
+
+	return helpRow{
+		group:       usage.Group,
+		flags:       strings.Join(names, ", "),
+		env:         strings.Join(usage.Env, ", "),
+		defaultText: usage.Default,
+		description: description,
+	}
+}
+
+/*
+writeRows writes help lines in aligned columns, grouped under headings.
+The columns line up across groups. Descriptions that don't fit in the
+width are wrapped onto following lines, indented to the description
+column.
+*/
+func writeRows(w io.Writer, rows []helpRow, style helpStyle) {
+	var (
+		groups []string
+	)
+
+	byGroup := make(map[string][]helpRow)
+	header := helpRow{flags: "FLAG", env: "ENV", defaultText: "DEFAULT", description: "DESCRIPTION"}
+	widths := columnWidths(append([]helpRow{header}, rows...))
+
+	for _, row := range rows {
+		if _, ok := byGroup[row.group]; !ok && row.group != "" {
+			groups = append(groups, row.group)
+		}
+
+		byGroup[row.group] = append(byGroup[row.group], row)
+	}
+
+	writeRow(w, header, widths, style, headerColors)
+
+	for _, row := range byGroup[""] {
+		writeRow(w, row, widths, style, rowColors)
+	}
+
+	for _, group := range groups {
+		fmt.Fprintf(w, "\n%s\n", style.paint(group+":", colorBold))
+
+		for _, row := range byGroup[group] {
+			writeRow(w, row, widths, style, rowColors)
+		}
+	}
+}
+
+/*
+columnWidths returns the widths of the flag, env, and default columns.
+*/
+func columnWidths(rows []helpRow) [3]int {
+	var (
+		widths [3]int
+	)
+
+	for _, row := range rows {
+		for i, text := range []string{row.flags, row.env, row.defaultText} {
+			if length := utf8.RuneCountInString(text); length > widths[i] {
+				widths[i] = length
+			}
+		}
+	}
+
+	return widths
+}
+
+/*
+writeRow writes one help line. Padding is added outside the color
+codes, so colored columns line up with plain ones.
+*/
+func writeRow(w io.Writer, row helpRow, widths [3]int, style helpStyle, colors [4]string) {
+	const (
+		indent = 2
+		gap    = 3
+	)
+
+	var (
+		line strings.Builder
+	)
+
+	line.WriteString(strings.Repeat(" ", indent))
+
+	for i, text := range []string{row.flags, row.env, row.defaultText} {
+		line.WriteString(style.paint(text, colors[i]))
+		line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(text)+gap))
+	}
+
+	descriptionColumn := indent + widths[0] + widths[1] + widths[2] + 3*gap
+	descriptionLines := wrapText(row.description, style.width-descriptionColumn)
+
+	if len(descriptionLines) == 0 {
+		descriptionLines = []string{""}
+	}
+
+	line.WriteString(style.paint(descriptionLines[0], colors[3]))
+	fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+
+	for _, text := range descriptionLines[1:] {
+		fmt.Fprintln(w, strings.Repeat(" ", descriptionColumn)+style.paint(text, colors[3]))
+	}
+}
+
+/*
+wrapText splits text into lines of at most width characters, breaking
+between words. Words longer than width are left on a line of their own.
+Widths too narrow to be readable are raised to a minimum.
+*/
+func wrapText(text string, width int) []string {
+	const (
+		minWidth = 20
+	)
+
+	var (
+		lines   []string
+		current string
+	)
+
+	if width < minWidth {
+		width = minWidth
+	}
+
+	for _, word := range strings.Fields(text) {
+		if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current = ""
+		}
+
+		if current != "" {
+			current += " "
+		}
+
+		current += word
+	}
+
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	return lines
+}
+
+/*
+paint wraps text in an ANSI color when the style is colored.
+*/
+func (s helpStyle) paint(text, color string) string {
+	if !s.color || color == "" || text == "" {
+		return text
+	}
+
+	return color + text + colorReset
+}
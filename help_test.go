@@ -0,0 +1,82 @@
+package configinator
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+func TestUsageRow(t *testing.T) {
+	tests := []struct {
+		name   string
+		usage  container.Usage
+		dashes string
+		want   string
+	}{
+		{name: "flag", usage: container.Usage{Flag: "host", Type: "string"}, dashes: "-", want: "-host string"},
+		{name: "short flag", usage: container.Usage{Flag: "port", Short: "p", Type: "int"}, dashes: "--", want: "-p, --port int"},
+		{name: "bool", usage: container.Usage{Flag: "debug", Type: "bool", IsBool: true}, dashes: "-", want: "-debug"},
+		{name: "negated bool", usage: container.Usage{Flag: "color", Negated: "no-color", Type: "bool", IsBool: true}, dashes: "--", want: "--color, --no-color"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := usageRow(test.usage, test.dashes).flags; got != test.want {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestHelpOutput(t *testing.T) {
+	type config struct {
+		Host    string        `flag:"host" short:"H" env:"HOST" default:"localhost:8080" description:"Host and port to bind to"`
+		Timeout time.Duration `flag:"timeout" default:"5s" description:"Request timeout"`
+		Color   bool          `flag:"color" default:"true" description:"Color output"`
+		Level   string        `flag:"level" env:"LOG_LEVEL" oneof:"debug,info" default:"info" description:"Log level"`
+		Token   string        `env:"TOKEN" only:"env" description:"API token"`
+		DB      struct {
+			Password string `flag:"password" env:"PASSWORD" required:"true" group:"Database" description:"Database password"`
+		} `prefix:"db"`
+	}
+
+	var (
+		got    config
+		output bytes.Buffer
+	)
+
+	t.Setenv("COLUMNS", "100")
+
+	flags := flag.NewFlagSet("mytool", flag.ContinueOnError)
+	flags.SetOutput(&output)
+
+	if err := BeholdE(&got, WithFlagSet(flags), WithArgs([]string{"-help"})); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("expected flag.ErrHelp, got %v", err)
+	}
+
+	/*
+	 * Fields only set from the environment, such as Token, are left out
+	 */
+	want := `Usage: mytool [flags]
+
+Options:
+  FLAG                  ENV           DEFAULT          DESCRIPTION
+  -H, -host string      HOST          localhost:8080   Host and port to bind to
+  -timeout duration                   5s               Request timeout
+  -color, -no-color                   true             Color output
+  -level string         LOG_LEVEL     info             Log level (one of: debug, info)
+  -config string                                       Path to a config file. Repeat to overlay
+                                                       several files
+
+Database:
+  -db-password string   DB_PASSWORD                    Database password (required)
+`
+
+	if output.String() != want {
+		t.Errorf("expected\n%q\ngot\n%q", want, output.String())
+	}
+}
@@ -0,0 +1,246 @@
+package configinator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+DefaultHTTPTimeout is how long an HTTPSource waits for a response when
+it doesn't set a timeout.
+*/
+const DefaultHTTPTimeout = 10 * time.Second
+
+var (
+	httpCache     = make(map[string]httpCacheEntry)
+	httpCacheLock sync.Mutex
+)
+
+/*
+HTTPSource fetches a config document from a URL, such as an internal
+config service. The document can be JSON, YAML, XML, HCL, tfvars, or
+env formatted. Set Format to one of the configfile formats, or FormatEnv,
+or leave it empty to work the format out from the Content-Type header,
+the extension of the URL's path, or the document itself.
+
+Headers are added to the request. Set Username and Password for basic
+auth, or Token for a bearer token.
+
+With CacheTTL set, the document is cached for the life of the process,
+so loading config again, such as on a periodic refresh, doesn't hammer
+the config service. The cache is keyed by the URL, credentials, headers,
+and client together. Within the TTL the cached document is used. After
+it, the document is fetched again with If-None-Match and
+If-Modified-Since, and the cached document is kept when the server says
+it hasn't changed.
+*/
+type HTTPSource struct {
+	URL      string
+	Format   string
+	Headers  http.Header
+	Username string
+	Password string
+	Token    string
+	Timeout  time.Duration
+	Client   *http.Client
+	CacheTTL time.Duration
+}
+
+type httpCacheEntry struct {
+	data         []byte
+	contentType  string
+	etag         string
+	lastModified string
+	fetched      time.Time
+}
+
+/*
+WithHTTP fetches a config document from a URL. Env documents override
+the .env file, and other documents are placed over the config file.
+Sources are applied in the order given.
+*/
+func WithHTTP(source HTTPSource) Option {
+	return func(o *options) {
+		o.httpSources = append(o.httpSources, source)
+	}
+}
+
+func (s HTTPSource) apply(ctx context.Context, files *container.Files) error {
+	var (
+		err      error
+		entry    httpCacheEntry
+		location *url.URL
+	)
+
+	if location, err = url.Parse(s.URL); err != nil {
+		return fmt.Errorf("invalid config URL: %w", err)
+	}
+
+	if entry, err = s.fetch(ctx); err != nil {
+		return fmt.Errorf("error fetching config from %s: %w", location.Redacted(), err)
+	}
+
+	format := s.Format
+
+	if format == "" {
+		format = documentFormat(entry.contentType, location.Path, entry.data)
+	}
+
+	if err = applyDocument(files, format, entry.data); err != nil {
+		return fmt.Errorf("error parsing config from %s: %w", location.Redacted(), err)
+	}
+
+	return nil
+}
+
+/*
+fetch gets the document, from the cache when it is fresh.
+*/
+func (s HTTPSource) fetch(ctx context.Context) (httpCacheEntry, error) {
+	var (
+		err      error
+		request  *http.Request
+		response *http.Response
+		data     []byte
+	)
+
+	httpCacheLock.Lock()
+	cached, isCached := httpCache[s.cacheKey()]
+	httpCacheLock.Unlock()
+
+	if s.CacheTTL > 0 && isCached && time.Since(cached.fetched) < s.CacheTTL {
+		return cached, nil
+	}
+
+	if request, err = http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil); err != nil {
+		return httpCacheEntry{}, err
+	}
+
+	for name, values := range s.Headers {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+
+	if s.Username != "" || s.Password != "" {
+		request.SetBasicAuth(s.Username, s.Password)
+	}
+
+	if s.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	if s.CacheTTL > 0 && isCached {
+		if cached.etag != "" {
+			request.Header.Set("If-None-Match", cached.etag)
+		}
+
+		if cached.lastModified != "" {
+			request.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	if response, err = s.client().Do(request); err != nil {
+		return httpCacheEntry{}, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && s.CacheTTL > 0 && isCached {
+		cached.fetched = time.Now()
+		s.store(cached)
+		return cached, nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return httpCacheEntry{}, fmt.Errorf("%s", response.Status)
+	}
+
+	if data, err = io.ReadAll(response.Body); err != nil {
+		return httpCacheEntry{}, err
+	}
+
+	result := httpCacheEntry{
+		data:         data,
+		contentType:  response.Header.Get("Content-Type"),
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+		fetched:      time.Now(),
+	}
+
+	if s.CacheTTL > 0 {
+		s.store(result)
+	}
+
+	return result, nil
+}
+
+func (s HTTPSource) store(entry httpCacheEntry) {
+	httpCacheLock.Lock()
+	defer httpCacheLock.Unlock()
+
+	httpCache[s.cacheKey()] = entry
+}
+
+/*
+cacheKey identifies the document in the cache. Sources fetching the
+same URL with other credentials, headers, or clients may be given
+other documents, so each gets its own entry. The key is hashed so the
+cache doesn't hold credentials.
+*/
+func (s HTTPSource) cacheKey() string {
+	hash := sha256.New()
+
+	write := func(value string) {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+
+	write(s.URL)
+	write(s.Username)
+	write(s.Password)
+	write(s.Token)
+	write(fmt.Sprintf("%p", s.Client))
+
+	names := make([]string, 0, len(s.Headers))
+
+	for name := range s.Headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range s.Headers[name] {
+			write(name + ": " + value)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (s HTTPSource) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+
+	timeout := s.Timeout
+
+	if timeout == 0 {
+		timeout = DefaultHTTPTimeout
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+	}
+}
@@ -0,0 +1,54 @@
+package configinator
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+KeyringSource reads secrets from the OS credential store, for desktop
+and command line apps that must not keep tokens in plain text files.
+Each of Keys is looked up as an account of Service, and matched to
+fields by env name. Keys that aren't stored are skipped.
+
+On macOS the login Keychain is read with the security tool. On Linux
+the Secret Service (GNOME Keyring, KWallet) is read with secret-tool,
+from libsecret, looking up the attributes service and account. On
+Windows the Credential Manager is read for generic credentials named
+"<service>:<key>".
+
+	configinator.WithSource(configinator.KeyringSource{
+		Service: "myapp",
+		Keys:    []string{"API_TOKEN"},
+	})
+*/
+type KeyringSource struct {
+	Service string
+	Keys    []string
+}
+
+func (s KeyringSource) Name() string {
+	return "keyring"
+}
+
+func (s KeyringSource) Load(ctx context.Context) (map[string]string, error) {
+	var (
+		err   error
+		value string
+		ok    bool
+	)
+
+	result := make(map[string]string, len(s.Keys))
+
+	for _, key := range s.Keys {
+		if value, ok, err = keyringLookup(ctx, s.Service, key); err != nil {
+			return result, fmt.Errorf("error reading %s from the keyring: %w", key, err)
+		}
+
+		if ok {
+			result[key] = value
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,24 @@
+//go:build darwin
+
+package configinator
+
+import (
+	"context"
+	"strings"
+
+	"github.com/app-nerds/configinator/configfile"
+)
+
+func keyringLookup(ctx context.Context, service, key string) (string, bool, error) {
+	output, err := configfile.RunToolContext(ctx, "security", "find-generic-password", "-s", service, "-a", key, "-w")
+
+	if err != nil {
+		if strings.Contains(err.Error(), "could not be found") {
+			return "", false, nil
+		}
+
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(output), "\n"), true, nil
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package configinator
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/app-nerds/configinator/configfile"
+)
+
+func keyringLookup(ctx context.Context, service, key string) (string, bool, error) {
+	var (
+		exitErr *exec.ExitError
+	)
+
+	output, err := configfile.RunToolContext(ctx, "secret-tool", "lookup", "service", service, "account", key)
+
+	if err != nil {
+		/*
+		 * secret-tool exits with 1, and says nothing, when the secret isn't stored
+		 */
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", false, nil
+		}
+
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(output), "\n"), true, nil
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !linux && !windows
+
+package configinator
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+func keyringLookup(ctx context.Context, service, key string) (string, bool, error) {
+	return "", false, fmt.Errorf("the keyring is not supported on %s", runtime.GOOS)
+}
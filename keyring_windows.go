@@ -0,0 +1,66 @@
+//go:build windows
+
+package configinator
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32     = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead = advapi32.NewProc("CredReadW")
+	procCredFree = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric = 1
+	errorNotFound   = syscall.Errno(1168)
+)
+
+/*
+credential mirrors the Win32 CREDENTIALW struct.
+*/
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func keyringLookup(ctx context.Context, service, key string) (string, bool, error) {
+	var (
+		err    error
+		target *uint16
+		cred   *credential
+	)
+
+	if target, err = syscall.UTF16PtrFromString(service + ":" + key); err != nil {
+		return "", false, err
+	}
+
+	ok, _, callErr := procCredRead.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+
+	if ok == 0 {
+		if errors.Is(callErr, errorNotFound) {
+			return "", false, nil
+		}
+
+		return "", false, callErr
+	}
+
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	return string(blob), true, nil
+}
@@ -0,0 +1,60 @@
+package configinator
+
+import (
+	"context"
+	"flag"
+	"os"
+)
+
+/*
+Loader loads config with its own flag set and options, rather than the
+global flag.CommandLine that Behold uses. Two components in one process
+can each have a Loader, and load their own config without their flags
+colliding. Flags on a Loader's flag set that fail to parse, or -help,
+are returned as errors rather than exiting.
+
+	loader := configinator.NewLoader(configinator.WithPrefix("api"))
+
+	if err := loader.Load(&config); err != nil {
+		log.Fatal(err)
+	}
+
+Loading again with the same Loader reuses the flags already parsed.
+*/
+type Loader struct {
+	flags *flag.FlagSet
+	opts  []Option
+}
+
+/*
+NewLoader creates a Loader with the given options.
+*/
+func NewLoader(opts ...Option) *Loader {
+	return &Loader{
+		flags: flag.NewFlagSet(os.Args[0], flag.ContinueOnError),
+		opts:  opts,
+	}
+}
+
+/*
+FlagSet returns the flag set fields add their flags to, so its usage
+can be printed or more flags added.
+*/
+func (l *Loader) FlagSet() *flag.FlagSet {
+	return l.flags
+}
+
+/*
+Load initializes a provided struct like BeholdE.
+*/
+func (l *Loader) Load(config interface{}) error {
+	return l.LoadContext(context.Background(), config)
+}
+
+/*
+LoadContext initializes a provided struct like BeholdContextE.
+*/
+func (l *Loader) LoadContext(ctx context.Context, config interface{}) error {
+	opts := append([]Option{WithFlagSet(l.flags)}, l.opts...)
+	return BeholdContextE(ctx, config, opts...)
+}
@@ -0,0 +1,57 @@
+package configinator
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+/*
+LogLevel is a config field type for slog levels. It accepts
+"debug", "info", "warn" (or "warning"), and "error" from any
+source, case insensitive. Offsets such as "info+2" are also
+accepted. For example:
+
+	type Config struct {
+		LogLevel configinator.LogLevel `flag:"loglevel" env:"LOG_LEVEL" default:"info"`
+	}
+
+LogLevel implements slog.Leveler, so it can be passed straight
+to slog.HandlerOptions.
+*/
+type LogLevel slog.Level
+
+/*
+Level returns the level as a slog.Level.
+*/
+func (l LogLevel) Level() slog.Level {
+	return slog.Level(l)
+}
+
+/*
+Set parses a level name. This satisfies flag.Value.
+*/
+func (l *LogLevel) Set(value string) error {
+	var (
+		level slog.Level
+	)
+
+	if strings.EqualFold(value, "warning") {
+		value = "warn"
+	}
+
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return fmt.Errorf("%q is not a valid log level. expected debug, info, warn, or error", value)
+	}
+
+	*l = LogLevel(level)
+	return nil
+}
+
+func (l *LogLevel) String() string {
+	if l == nil {
+		return slog.LevelInfo.String()
+	}
+
+	return strings.ToLower(slog.Level(*l).String())
+}
@@ -0,0 +1,64 @@
+package configinator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapFields(t *testing.T) {
+	type config struct {
+		Limits   map[string]int     `flag:"rate-limit" env:"RATE_LIMITS"`
+		Features map[string]bool    `flag:"feature" env:"FEATURES"`
+		Weights  map[string]float64 `flag:"weight"`
+		Labels   map[string]string  `flag:"label" duplicates:"first"`
+		Strict   map[string]string  `flag:"strict" duplicates:"error"`
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want config
+		err  bool
+	}{
+		{name: "env", env: map[string]string{"RATE_LIMITS": "read=100, write=20"}, want: config{Limits: map[string]int{"read": 100, "write": 20}}},
+		{name: "bool values", env: map[string]string{"FEATURES": "beta=true,legacy=false"}, want: config{Features: map[string]bool{"beta": true, "legacy": false}}},
+		{name: "repeated flags", args: []string{"-rate-limit", "read=100", "-rate-limit", "write=20"}, want: config{Limits: map[string]int{"read": 100, "write": 20}}},
+		{name: "float values", args: []string{"-weight", "a=0.5"}, want: config{Weights: map[string]float64{"a": 0.5}}},
+		{name: "last value wins", args: []string{"-rate-limit", "read=1,read=2"}, want: config{Limits: map[string]int{"read": 2}}},
+		{name: "first value wins", args: []string{"-label", "team=a,team=b"}, want: config{Labels: map[string]string{"team": "a"}}},
+		{name: "duplicate is an error", args: []string{"-strict", "a=1,a=2"}, err: true},
+		{name: "invalid value", args: []string{"-rate-limit", "read=lots"}, err: true},
+		{name: "not a pair", args: []string{"-feature", "beta"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			err := beholdArgs(&got, test.args...)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package configinator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cloud providers for MetadataSource
+const (
+	MetadataAWS string = "aws"
+	MetadataGCP string = "gcp"
+)
+
+/*
+DefaultMetadataTimeout is how long a MetadataSource waits for each
+request when it doesn't set a timeout.
+*/
+const DefaultMetadataTimeout = 2 * time.Second
+
+var (
+	metadataEndpoints = map[string]string{
+		MetadataAWS: "http://169.254.169.254/latest",
+		MetadataGCP: "http://metadata.google.internal/computeMetadata/v1",
+	}
+)
+
+/*
+MetadataSource reads the cloud instance metadata service, so instances
+configure themselves without extra env plumbing. Paths maps names, such
+as env names, to metadata paths. On AWS these are under meta-data, such
+as "placement/region". On GCP they are under computeMetadata/v1, such as
+"instance/zone".
+
+With Tags set, the instance's tags are read too, keyed by tag name. On
+AWS this needs access to tags in instance metadata turned on. On GCP the
+instance's custom metadata attributes are read.
+
+	configinator.WithSource(configinator.MetadataSource{
+		Provider: configinator.MetadataAWS,
+		Paths:    map[string]string{"REGION": "placement/region"},
+		Tags:     true,
+	})
+
+AWS is read with IMDSv2 session tokens. Endpoint overrides the metadata
+address, and requests time out after Timeout, or
+DefaultMetadataTimeout when it isn't set.
+*/
+type MetadataSource struct {
+	Provider string
+	Paths    map[string]string
+	Tags     bool
+	Endpoint string
+	Timeout  time.Duration
+}
+
+func (s MetadataSource) Name() string {
+	return "metadata"
+}
+
+func (s MetadataSource) Load(ctx context.Context) (map[string]string, error) {
+	var (
+		err    error
+		token  string
+		value  string
+		result map[string]string
+	)
+
+	endpoint := s.Endpoint
+
+	if endpoint == "" {
+		endpoint = metadataEndpoints[s.Provider]
+	}
+
+	if endpoint == "" {
+		return nil, fmt.Errorf("unknown metadata provider '%s'", s.Provider)
+	}
+
+	timeout := s.Timeout
+
+	if timeout == 0 {
+		timeout = DefaultMetadataTimeout
+	}
+
+	client := &metadataClient{
+		client:   &http.Client{Timeout: timeout},
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		provider: s.Provider,
+	}
+
+	if s.Provider == MetadataAWS {
+		if token, err = client.awsToken(ctx); err != nil {
+			return nil, err
+		}
+
+		client.token = token
+	}
+
+	result = make(map[string]string)
+
+	for name, path := range s.Paths {
+		if value, err = client.get(ctx, path); err != nil {
+			return nil, err
+		}
+
+		result[name] = value
+	}
+
+	if s.Tags {
+		if err = client.tags(ctx, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+type metadataClient struct {
+	client   *http.Client
+	endpoint string
+	provider string
+	token    string
+}
+
+/*
+awsToken gets an IMDSv2 session token.
+*/
+func (c *metadataClient) awsToken(ctx context.Context) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, c.endpoint+"/api/token", nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	return c.do(request)
+}
+
+func (c *metadataClient) get(ctx context.Context, path string) (string, error) {
+	location := c.endpoint + "/" + strings.TrimPrefix(path, "/")
+
+	if c.provider == MetadataAWS {
+		location = c.endpoint + "/meta-data/" + strings.TrimPrefix(path, "/")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	if c.provider == MetadataAWS {
+		request.Header.Set("X-aws-ec2-metadata-token", c.token)
+	} else {
+		request.Header.Set("Metadata-Flavor", "Google")
+	}
+
+	return c.do(request)
+}
+
+func (c *metadataClient) tags(ctx context.Context, result map[string]string) error {
+	var (
+		err        error
+		value      string
+		attributes map[string]string
+	)
+
+	if c.provider == MetadataGCP {
+		if value, err = c.get(ctx, "instance/attributes/?recursive=true"); err != nil {
+			return err
+		}
+
+		if err = json.Unmarshal([]byte(value), &attributes); err != nil {
+			return fmt.Errorf("error reading instance attributes: %w", err)
+		}
+
+		for name, attribute := range attributes {
+			result[name] = attribute
+		}
+
+		return nil
+	}
+
+	if value, err = c.get(ctx, "tags/instance"); err != nil {
+		return err
+	}
+
+	for _, name := range strings.Fields(value) {
+		if result[name], err = c.get(ctx, "tags/instance/"+name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *metadataClient) do(request *http.Request) (string, error) {
+	var (
+		err      error
+		response *http.Response
+		data     []byte
+	)
+
+	if response, err = c.client.Do(request); err != nil {
+		return "", fmt.Errorf("error reading instance metadata: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error reading instance metadata %s: %s", request.URL.Path, response.Status)
+	}
+
+	if data, err = io.ReadAll(response.Body); err != nil {
+		return "", fmt.Errorf("error reading instance metadata: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,122 @@
+package configinator
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+NamingStrategy turns a struct field name, such as MaxRetries, into a
+flag or env name. With WithFlagNaming or WithEnvNaming, fields without
+a flag or env tag are named by the strategy, so a config struct needs
+no tags at all, like envconfig's split_words.
+*/
+type NamingStrategy func(fieldName string) string
+
+/*
+ScreamingSnake names MaxRetries as MAX_RETRIES.
+*/
+func ScreamingSnake(fieldName string) string {
+	return strings.ToUpper(strings.Join(splitWords(fieldName), "_"))
+}
+
+/*
+KebabCase names MaxRetries as max-retries.
+*/
+func KebabCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "-"))
+}
+
+/*
+LowerCamel names MaxRetries as maxRetries, and HTTPPort as httpPort.
+*/
+func LowerCamel(fieldName string) string {
+	words := splitWords(fieldName)
+
+	for index, word := range words {
+		if index == 0 {
+			words[index] = strings.ToLower(word)
+			continue
+		}
+
+		words[index] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	}
+
+	return strings.Join(words, "")
+}
+
+/*
+WithFlagNaming names the flags of fields without a flag tag using the
+strategy, rather than skipping them. Struct fields are still walked as
+nested structs.
+
+	configinator.Behold(&config, configinator.WithFlagNaming(configinator.KebabCase))
+*/
+func WithFlagNaming(strategy NamingStrategy) Option {
+	return func(o *options) {
+		o.flagNaming = strategy
+	}
+}
+
+/*
+WithEnvNaming names the env variables of fields without an env tag
+using the strategy.
+
+	configinator.Behold(&config, configinator.WithEnvNaming(configinator.ScreamingSnake))
+*/
+func WithEnvNaming(strategy NamingStrategy) Option {
+	return func(o *options) {
+		o.envNaming = strategy
+	}
+}
+
+/*
+splitWords splits a field name into words where the case changes, so
+MaxRetries is Max and Retries, and HTTPServer is HTTP and Server.
+Underscores separate words too. Digits stay with the word before them.
+*/
+func splitWords(fieldName string) []string {
+	var (
+		result []string
+	)
+
+	runes := []rune(fieldName)
+	start := 0
+
+	for index := 0; index < len(runes); index++ {
+		current := runes[index]
+
+		/*
+		 * Underscores only separate words, so Max__Retries and _Max
+		 * don't give empty words
+		 */
+		if current == '_' {
+			if index > start {
+				result = append(result, string(runes[start:index]))
+			}
+
+			start = index + 1
+			continue
+		}
+
+		if index == 0 {
+			continue
+		}
+
+		previous := runes[index-1]
+
+		lowerToUpper := unicode.IsUpper(current) && !unicode.IsUpper(previous)
+		acronymEnd := unicode.IsUpper(current) && unicode.IsUpper(previous) && index+1 < len(runes) && unicode.IsLower(runes[index+1])
+
+		if (lowerToUpper || acronymEnd) && index > start {
+			result = append(result, string(runes[start:index]))
+			start = index
+		}
+	}
+
+	if start < len(runes) {
+		result = append(result, string(runes[start:]))
+	}
+
+	return result
+}
@@ -0,0 +1,82 @@
+package configinator
+
+import (
+	"reflect"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+Optional wraps a config value and records whether it was provided, and
+by which source. This answers "was this actually set?" without using
+pointers. For example:
+
+	type Config struct {
+		Timeout configinator.Optional[int] `flag:"timeout" env:"TIMEOUT"`
+	}
+
+	if config.Timeout.IsSet() {
+		...
+	}
+
+A value from the default tag is applied, but does not count as being set.
+*/
+type Optional[T any] struct {
+	value  T
+	set    bool
+	source string
+}
+
+/*
+Get returns the value. This is the zero value of T if nothing was provided.
+*/
+func (o Optional[T]) Get() T {
+	return o.value
+}
+
+/*
+GetOr returns the value if one was provided by a source other than the
+default tag, otherwise it returns fallback.
+*/
+func (o Optional[T]) GetOr(fallback T) T {
+	if !o.set {
+		return fallback
+	}
+
+	return o.value
+}
+
+/*
+IsSet returns true if a value was provided by the environment,
+.env file, or a flag.
+*/
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+/*
+Source returns where the value came from, such as "env" or "flag". It is
+"default" if the value came from the default tag, and empty if there
+was no value at all.
+*/
+func (o Optional[T]) Source() string {
+	return o.source
+}
+
+/*
+OptionalType returns the type of the wrapped value. This satisfies
+container.Optional.
+*/
+func (o *Optional[T]) OptionalType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+/*
+SetOptional sets the value and records its source. This satisfies
+container.Optional.
+*/
+func (o *Optional[T]) SetOptional(value interface{}, source string) {
+	o.value = value.(T)
+	o.source = source
+	o.set = source != container.SourceDefault
+}
@@ -0,0 +1,348 @@
+package configinator
+
+import (
+	"flag"
+	"io"
+	"io/fs"
+	"os"
+)
+
+/*
+AppEnvVariable is the environment variable naming the environment the
+app runs in, such as "production". When it is set, env files are read
+as a cascade. See WithAppEnv.
+*/
+const AppEnvVariable string = "APP_ENV"
+
+/*
+DefaultSecretDir is where Docker mounts secrets.
+*/
+const DefaultSecretDir string = "/run/secrets"
+
+/*
+CredentialsDirectoryVariable is the environment variable systemd sets
+to the directory holding a service's credentials.
+*/
+const CredentialsDirectoryVariable string = "CREDENTIALS_DIRECTORY"
+
+/*
+Option customizes how Behold loads configuration.
+*/
+type Option func(*options)
+
+type options struct {
+	args         []string
+	ageIdentity  string
+	appEnv       string
+	configFiles  []string
+	configFlag   string
+	decrypter    Decrypter
+	defaults     fs.FS
+	envCascade   bool
+	envDirs      []string
+	envFiles     []string
+	envNaming    NamingStrategy
+	envPrefix    string
+	fetchWorkers int
+	fileValues   bool
+	flagNaming   NamingStrategy
+	flags        *flag.FlagSet
+	gnuFlags     bool
+	httpSources  []HTTPSource
+	mountedDirs  []string
+	noColor      bool
+	onChange     func()
+	prefix       string
+	precedence   []string
+	redisSources []RedisSource
+	s3Sources    []S3Source
+	searchDirs   []string
+	secretDirs   []string
+	sources      []Source
+	sqlSources   []SQLSource
+	stdinFormat  string
+	tagPrefix    string
+	validator    StructValidator
+	version      *Version
+	warnings     io.Writer
+}
+
+func newOptions(opts []Option) *options {
+	result := &options{
+		args:         os.Args[1:],
+		configFlag:   DefaultConfigFlag,
+		envFiles:     []string{".env"},
+		fetchWorkers: DefaultFetchWorkers,
+		flags:        flag.CommandLine,
+		secretDirs:   []string{DefaultSecretDir},
+		warnings:     os.Stderr,
+	}
+
+	result.appEnv, result.envCascade = os.LookupEnv(AppEnvVariable)
+
+	for _, opt := range opts {
+		opt(result)
+	}
+
+	return result
+}
+
+/*
+WithEnvFile sets the env files to read, instead of .env in the working
+directory. Files are read in order, with values in later files
+overriding earlier ones. Files that don't exist are skipped.
+*/
+func WithEnvFile(fileNames ...string) Option {
+	return func(o *options) {
+		o.envFiles = fileNames
+	}
+}
+
+/*
+WithoutEnvFile turns off reading env files.
+*/
+func WithoutEnvFile() Option {
+	return func(o *options) {
+		o.envFiles = nil
+	}
+}
+
+/*
+WithEnvDir reads envdir style directories, as used by daemontools and
+runit, where each file is a variable named after the file holding its
+value. These are read after env files, and override them. Directories
+from repeated calls are read in order, with later ones overriding
+earlier ones.
+*/
+func WithEnvDir(dirs ...string) Option {
+	return func(o *options) {
+		o.envDirs = append(o.envDirs, dirs...)
+	}
+}
+
+/*
+WithSecretDir sets the directories secret files are read from, instead
+of /run/secrets. Each file holds one secret named after the file. Files
+in later directories override earlier ones. The systemd credentials
+directory is always used. Only the files fields look for are read.
+*/
+func WithSecretDir(dirs ...string) Option {
+	return func(o *options) {
+		o.secretDirs = dirs
+	}
+}
+
+/*
+WithMountedDir reads directories with one file per key, such as a
+Kubernetes ConfigMap or Secret mounted as a volume. Keys are matched to
+fields like secret files, by the secretfile tag or the env name. These
+are read after the secret dirs, and override them.
+*/
+func WithMountedDir(dirs ...string) Option {
+	return func(o *options) {
+		o.mountedDirs = append(o.mountedDirs, dirs...)
+	}
+}
+
+/*
+WithArgs parses flags from the given arguments rather than os.Args, for
+tests and for tools that process their command line first. Like
+flag.Parse, the arguments don't include the program name.
+
+	configinator.Behold(&config, configinator.WithArgs([]string{"-host", "localhost:8080"}))
+*/
+func WithArgs(args []string) Option {
+	return func(o *options) {
+		o.args = args
+	}
+}
+
+/*
+WithEnvPrefix puts a prefix before every env name, so co-located
+services don't collide without repeating the prefix in every tag. With
+WithEnvPrefix("MYAPP_"), a field tagged env:"HOST" is read from
+MYAPP_HOST. This applies to the environment, .env files, _FILE
+variables, and secret files named after the env name.
+*/
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+/*
+WithFlagSet adds flags to, and parses, the given flag set rather than
+flag.CommandLine, for programs that manage their own flag sets, such as
+one per subcommand. Parse errors are handled by the flag set's error
+handling mode, so with flag.ContinueOnError they are returned by BeholdE
+rather than exiting. Flags are parsed unless the flag set has been
+parsed already.
+
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	err := configinator.BeholdE(&config, configinator.WithFlagSet(flags))
+*/
+func WithFlagSet(flags *flag.FlagSet) Option {
+	return func(o *options) {
+		o.flags = flags
+	}
+}
+
+/*
+WithTagPrefix puts a prefix before the names of configinator's tags, so
+it can share a struct with other libraries that read the flag or env
+tags. With WithTagPrefix("cfg_"), fields are tagged cfg_flag, cfg_env,
+cfg_default, and so on. Config file tags, such as yaml and json, keep
+their names, as they are shared with those formats on purpose.
+*/
+func WithTagPrefix(prefix string) Option {
+	return func(o *options) {
+		o.tagPrefix = prefix
+	}
+}
+
+/*
+WithPrefix prefixes every field, like a prefix tag on a struct holding
+the config. With a prefix of "api", the host field is set with the flag
+"api-host", the env variable "API_HOST", or the key "host" under "api"
+in the config file. This lets components share one environment and
+config file without their names colliding.
+*/
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+/*
+WithAppEnv reads env files as a cascade for the named environment, such
+as "production", instead of using the APP_ENV variable. Each env file is
+followed by overrides, so .env is read as
+
+	.env
+	.env.production
+	.env.local
+	.env.production.local
+
+with later files overriding earlier ones. An empty name reads just the
+.local overrides.
+*/
+func WithAppEnv(name string) Option {
+	return func(o *options) {
+		o.appEnv = name
+		o.envCascade = true
+	}
+}
+
+/*
+WithConfigFile sets the config files to read. Files are merged in
+order, with values in later files overriding earlier ones, so an
+environment or local overlay can be placed on a base file. Files that
+don't exist are skipped. A file named "-" is read from stdin. The config
+flag takes precedence.
+*/
+func WithConfigFile(fileNames ...string) Option {
+	return func(o *options) {
+		o.configFiles = fileNames
+	}
+}
+
+/*
+WithConfigFlag changes the name of the flag used to choose a config
+file at runtime from "config". An empty name turns the flag off.
+*/
+func WithConfigFlag(name string) Option {
+	return func(o *options) {
+		o.configFlag = name
+	}
+}
+
+/*
+WithStdinConfig reads the config document from stdin, as if "-config -"
+were passed. The format is one of the configfile formats, such as
+configfile.FormatYAML. An empty format is guessed from the document.
+*/
+func WithStdinConfig(format string) Option {
+	return func(o *options) {
+		o.configFiles = []string{StdinConfig}
+		o.stdinFormat = format
+	}
+}
+
+/*
+WithDefaults reads a .env file and config file from a file system,
+usually one embedded with go:embed, so a binary ships with defaults.
+These sit beneath the env and config files on disk, which override
+them. The config file is the first found named like those on disk,
+such as config.yaml.
+
+	//go:embed defaults
+	var defaults embed.FS
+
+	sub, _ := fs.Sub(defaults, "defaults")
+	configinator.Behold(&config, configinator.WithDefaults(sub))
+*/
+func WithDefaults(fsys fs.FS) Option {
+	return func(o *options) {
+		o.defaults = fsys
+	}
+}
+
+/*
+WithSearchPaths searches the OS conventional locations for config and
+env files, so packaged binaries find their config. In order, these are
+the working directory, ~/.config/<app> (or $XDG_CONFIG_HOME/<app>),
+~/.<app>, /etc/xdg/<app> (or each of $XDG_CONFIG_DIRS), and /etc/<app>.
+The first config file found is read, named config.yaml, config.json,
+and so on. Env files are read from every location, with earlier
+locations overriding later ones.
+*/
+func WithSearchPaths(app string) Option {
+	return func(o *options) {
+		o.searchDirs = searchDirs(app)
+	}
+}
+
+/*
+secretDirNames returns the directories to read secret files from,
+including mounted dirs. The systemd credentials directory comes last,
+so its files win, as they are meant for this service alone.
+*/
+func (o *options) secretDirNames() []string {
+	result := make([]string, 0, len(o.secretDirs)+len(o.mountedDirs)+1)
+	result = append(result, o.secretDirs...)
+	result = append(result, o.mountedDirs...)
+
+	if dir := os.Getenv(CredentialsDirectoryVariable); dir != "" {
+		result = append(result, dir)
+	}
+
+	return result
+}
+
+/*
+envFileNames returns the env files to read, in order, expanded into
+the cascade when there is an app env, and placed in each search dir.
+*/
+func (o *options) envFileNames() []string {
+	if !o.envCascade {
+		return searchEnvFiles(o.envFiles, o.searchDirs)
+	}
+
+	result := make([]string, 0, len(o.envFiles)*4)
+
+	for _, fileName := range o.envFiles {
+		result = append(result, fileName)
+
+		if o.appEnv != "" {
+			result = append(result, fileName+"."+o.appEnv)
+		}
+
+		result = append(result, fileName+".local")
+
+		if o.appEnv != "" {
+			result = append(result, fileName+"."+o.appEnv+".local")
+		}
+	}
+
+	return searchEnvFiles(result, o.searchDirs)
+}
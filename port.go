@@ -0,0 +1,49 @@
+package configinator
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+/*
+Port is a config field type for TCP and UDP port numbers. It accepts 1
+through 65535 from any source, so a typo such as 80800 is caught when
+config is loaded. For example:
+
+	type Config struct {
+		Port configinator.Port `flag:"port" env:"PORT" default:"8080"`
+	}
+
+Add min and max tags to narrow the range, such as min:"1024" to rule
+out privileged ports. Add a bind tag of "true" to also check that the
+port is free to listen on when config is loaded.
+*/
+type Port uint16
+
+/*
+Set parses a port number. This satisfies flag.Value.
+*/
+func (p *Port) Set(value string) error {
+	port, err := strconv.ParseUint(value, 10, 16)
+
+	if err != nil || port == 0 {
+		return fmt.Errorf("%q is not a valid port. expected 1 to 65535", value)
+	}
+
+	*p = Port(port)
+	return nil
+}
+
+func (p Port) String() string {
+	return strconv.Itoa(int(p))
+}
+
+/*
+Addr joins a host and the port into an address for net.Listen or
+net.Dial, such as "localhost:8080". Use an empty host to listen on
+every interface.
+*/
+func (p Port) Addr(host string) string {
+	return net.JoinHostPort(host, p.String())
+}
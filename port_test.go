@@ -0,0 +1,30 @@
+package configinator
+
+import (
+	"testing"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+func TestPort(t *testing.T) {
+	runValidationTests(t, []validationTest{
+		{name: "valid", config: &struct {
+			Port Port `flag:"port"`
+		}{}, args: []string{"-port", "8080"}},
+		{name: "zero", config: &struct {
+			Port Port `flag:"port"`
+		}{}, args: []string{"-port", "0"}, err: container.ErrInvalid},
+		{name: "too large", config: &struct {
+			Port Port `flag:"port"`
+		}{}, args: []string{"-port", "80800"}, err: container.ErrInvalid},
+		{name: "not a number", config: &struct {
+			Port Port `flag:"port"`
+		}{}, args: []string{"-port", "http"}, err: container.ErrInvalid},
+		{name: "below min", config: &struct {
+			Port Port `flag:"port" min:"1024"`
+		}{}, args: []string{"-port", "443"}, err: container.ErrOutOfRange},
+		{name: "default below min", config: &struct {
+			Port Port `flag:"port" default:"80" min:"1024"`
+		}{}, err: container.ErrOutOfRange},
+	})
+}
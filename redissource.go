@@ -0,0 +1,316 @@
+package configinator
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+DefaultRedisTimeout is how long a RedisSource waits to connect and read
+its values when it doesn't set a timeout.
+*/
+const DefaultRedisTimeout = 10 * time.Second
+
+/*
+RedisSource reads config values from Redis, either every field of a
+hash, or every key starting with a prefix. Names are matched to env
+names, with the prefix removed, so with a Prefix of "myapp:" the key
+"myapp:DB_HOST" fills the field with the env name DB_HOST. Values
+override the .env file.
+
+Addr defaults to localhost:6379. Set TLS to connect with TLS. Requests
+time out after Timeout, or DefaultRedisTimeout when it isn't set.
+*/
+type RedisSource struct {
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	Key      string
+	Prefix   string
+	TLS      *tls.Config
+	Timeout  time.Duration
+}
+
+/*
+WithRedis reads config values from a Redis hash or key prefix. Sources
+are applied in the order given.
+*/
+func WithRedis(source RedisSource) Option {
+	return func(o *options) {
+		o.redisSources = append(o.redisSources, source)
+	}
+}
+
+func (s RedisSource) apply(ctx context.Context, files *container.Files) error {
+	var (
+		err    error
+		conn   net.Conn
+		values map[string]string
+	)
+
+	if (s.Key == "") == (s.Prefix == "") {
+		return fmt.Errorf("redis source needs either a Key or a Prefix")
+	}
+
+	addr := s.Addr
+
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	timeout := s.Timeout
+
+	if timeout == 0 {
+		timeout = DefaultRedisTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	if s.TLS != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.TLS}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error connecting to redis at %s: %w", addr, err)
+	}
+
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	if err = conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	client := &redisClient{conn: conn, reader: bufio.NewReader(conn)}
+
+	if values, err = s.load(client); err != nil {
+		return fmt.Errorf("error reading config from redis at %s: %w", addr, err)
+	}
+
+	for key, value := range values {
+		files.Env[key] = value
+	}
+
+	return nil
+}
+
+func (s RedisSource) load(client *redisClient) (map[string]string, error) {
+	var (
+		err   error
+		reply []string
+	)
+
+	if s.Password != "" {
+		args := []string{"AUTH", s.Password}
+
+		if s.Username != "" {
+			args = []string{"AUTH", s.Username, s.Password}
+		}
+
+		if _, err = client.do(args...); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.DB != 0 {
+		if _, err = client.do("SELECT", strconv.Itoa(s.DB)); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.Key != "" {
+		if reply, err = client.list("HGETALL", s.Key); err != nil {
+			return nil, err
+		}
+
+		return redisPairs(reply), nil
+	}
+
+	return s.loadPrefix(client)
+}
+
+/*
+loadPrefix finds the keys starting with the prefix using SCAN, which
+unlike KEYS doesn't block the server, then reads their values.
+*/
+func (s RedisSource) loadPrefix(client *redisClient) (map[string]string, error) {
+	var (
+		err   error
+		reply interface{}
+	)
+
+	result := make(map[string]string)
+	cursor := "0"
+	pattern := redisGlobEscape(s.Prefix) + "*"
+
+	for {
+		if reply, err = client.do("SCAN", cursor, "MATCH", pattern, "COUNT", "100"); err != nil {
+			return nil, err
+		}
+
+		parts, ok := reply.([]interface{})
+
+		if !ok || len(parts) != 2 {
+			return nil, fmt.Errorf("unexpected reply to SCAN")
+		}
+
+		cursor, _ = parts[0].(string)
+		keys := redisStrings(parts[1])
+
+		if len(keys) > 0 {
+			if reply, err = client.do(append([]string{"MGET"}, keys...)...); err != nil {
+				return nil, err
+			}
+
+			values, _ := reply.([]interface{})
+
+			for index, key := range keys {
+				if index < len(values) && values[index] != nil {
+					result[strings.TrimPrefix(key, s.Prefix)], _ = values[index].(string)
+				}
+			}
+		}
+
+		if cursor == "0" || cursor == "" {
+			return result, nil
+		}
+	}
+}
+
+/*
+redisClient speaks just enough of the Redis protocol (RESP) to read
+config, so programs don't need a Redis client library.
+*/
+type redisClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	var (
+		err error
+	)
+
+	command := &strings.Builder{}
+	fmt.Fprintf(command, "*%d\r\n", len(args))
+
+	for _, arg := range args {
+		fmt.Fprintf(command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if _, err = io.WriteString(c.conn, command.String()); err != nil {
+		return nil, err
+	}
+
+	return c.read()
+}
+
+func (c *redisClient) list(args ...string) ([]string, error) {
+	reply, err := c.do(args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return redisStrings(reply), nil
+}
+
+func (c *redisClient) read() (interface{}, error) {
+	var (
+		err  error
+		line string
+		size int
+	)
+
+	if line, err = c.reader.ReadString('\n'); err != nil {
+		return nil, err
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+
+	case '-':
+		return nil, errors.New(line[1:])
+
+	case '$':
+		if size, err = strconv.Atoi(line[1:]); err != nil || size < 0 {
+			return nil, err
+		}
+
+		data := make([]byte, size+2)
+
+		if _, err = io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+
+		return string(data[:size]), nil
+
+	case '*':
+		if size, err = strconv.Atoi(line[1:]); err != nil || size < 0 {
+			return nil, err
+		}
+
+		result := make([]interface{}, size)
+
+		for index := range result {
+			if result[index], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("unexpected reply '%s'", line)
+}
+
+func redisStrings(reply interface{}) []string {
+	items, _ := reply.([]interface{})
+	result := make([]string, len(items))
+
+	for index, item := range items {
+		result[index], _ = item.(string)
+	}
+
+	return result
+}
+
+func redisPairs(items []string) map[string]string {
+	result := make(map[string]string, len(items)/2)
+
+	for index := 0; index+1 < len(items); index += 2 {
+		result[items[index]] = items[index+1]
+	}
+
+	return result
+}
+
+func redisGlobEscape(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,177 @@
+package configinator
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedisClientRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  interface{}
+		err   bool
+	}{
+		{name: "simple string", reply: "+OK\r\n", want: "OK"},
+		{name: "integer", reply: ":42\r\n", want: "42"},
+		{name: "bulk string", reply: "$5\r\nhello\r\n", want: "hello"},
+		{name: "bulk string with CRLF", reply: "$7\r\nhi\r\nyou\r\n", want: "hi\r\nyou"},
+		{name: "empty bulk string", reply: "$0\r\n\r\n", want: ""},
+		{name: "nil bulk string", reply: "$-1\r\n", want: nil},
+		{name: "array", reply: "*2\r\n$1\r\na\r\n$1\r\nb\r\n", want: []interface{}{"a", "b"}},
+		{name: "array with nil", reply: "*2\r\n$1\r\na\r\n$-1\r\n", want: []interface{}{"a", nil}},
+		{name: "nested array", reply: "*2\r\n$1\r\n0\r\n*1\r\n$3\r\nkey\r\n", want: []interface{}{"0", []interface{}{"key"}}},
+		{name: "error", reply: "-WRONGPASS invalid password\r\n", err: true},
+		{name: "unknown type", reply: "!oops\r\n", err: true},
+		{name: "short bulk string", reply: "$10\r\nabc", err: true},
+		{name: "empty reply", reply: "\r\n", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &redisClient{reader: bufio.NewReader(strings.NewReader(test.reply))}
+			got, err := client.read()
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %#v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %#v, got %#v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRedisSourceLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   RedisSource
+		replies  []string
+		commands []string
+		want     map[string]string
+		err      bool
+	}{
+		{
+			name:     "hash",
+			source:   RedisSource{Key: "myapp"},
+			replies:  []string{"*4\r\n$7\r\nDB_HOST\r\n$2\r\ndb\r\n$4\r\nPORT\r\n$4\r\n5432\r\n"},
+			commands: []string{"HGETALL myapp"},
+			want:     map[string]string{"DB_HOST": "db", "PORT": "5432"},
+		},
+		{
+			name:     "auth and select",
+			source:   RedisSource{Key: "myapp", Username: "app", Password: "secret", DB: 2},
+			replies:  []string{"+OK\r\n", "+OK\r\n", "*0\r\n"},
+			commands: []string{"AUTH app secret", "SELECT 2", "HGETALL myapp"},
+			want:     map[string]string{},
+		},
+		{
+			name:   "prefix",
+			source: RedisSource{Prefix: "myapp:"},
+			replies: []string{
+				"*2\r\n$2\r\n17\r\n*2\r\n$13\r\nmyapp:DB_HOST\r\n$10\r\nmyapp:GONE\r\n",
+				"*2\r\n$2\r\ndb\r\n$-1\r\n",
+				"*2\r\n$1\r\n0\r\n*1\r\n$10\r\nmyapp:PORT\r\n",
+				"*1\r\n$4\r\n5432\r\n",
+			},
+			commands: []string{
+				"SCAN 0 MATCH myapp:* COUNT 100",
+				"MGET myapp:DB_HOST myapp:GONE",
+				"SCAN 17 MATCH myapp:* COUNT 100",
+				"MGET myapp:PORT",
+			},
+			want: map[string]string{"DB_HOST": "db", "PORT": "5432"},
+		},
+		{
+			name:     "prefix with glob characters",
+			source:   RedisSource{Prefix: "app*[1]:"},
+			replies:  []string{"*2\r\n$1\r\n0\r\n*0\r\n"},
+			commands: []string{`SCAN 0 MATCH app\*\[1\]:* COUNT 100`},
+			want:     map[string]string{},
+		},
+		{
+			name:     "auth failure",
+			source:   RedisSource{Key: "myapp", Password: "wrong"},
+			replies:  []string{"-WRONGPASS invalid password\r\n"},
+			commands: []string{"AUTH wrong"},
+			err:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+
+			commands := make(chan []string, 1)
+			go fakeRedis(server, test.replies, commands)
+
+			got, err := test.source.load(&redisClient{conn: client, reader: bufio.NewReader(client)})
+			client.Close()
+
+			if received := <-commands; !reflect.DeepEqual(received, test.commands) {
+				t.Errorf("expected commands %q, got %q", test.commands, received)
+			}
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+/*
+fakeRedis reads RESP commands from conn, answering each with the next
+reply, and sends the commands it read, space separated, when the
+connection is closed.
+*/
+func fakeRedis(conn net.Conn, replies []string, commands chan<- []string) {
+	var (
+		received []string
+	)
+
+	defer func() {
+		conn.Close()
+		commands <- received
+	}()
+
+	reader := bufio.NewReader(conn)
+
+	for _, reply := range replies {
+		command, err := (&redisClient{reader: reader}).read()
+
+		if err != nil {
+			return
+		}
+
+		received = append(received, strings.Join(redisStrings(command), " "))
+
+		if _, err = conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package configinator
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/app-nerds/configinator/configfile"
+	"github.com/app-nerds/configinator/container"
+	"github.com/app-nerds/configinator/env"
+)
+
+/*
+FormatEnv is the format of a remote document holding env variables,
+like an .env file.
+*/
+const FormatEnv string = "env"
+
+/*
+DefaultFetchWorkers is how many sources are fetched at once when
+WithFetchWorkers isn't used.
+*/
+const DefaultFetchWorkers int = 4
+
+/*
+remoteSource is a source given through an option, such as WithHTTP,
+whose document is merged into the values read from disk.
+*/
+type remoteSource interface {
+	apply(ctx context.Context, files *container.Files) error
+}
+
+/*
+WithFetchWorkers sets how many remote sources and custom sources are
+fetched at once. Sources are fetched concurrently so startup takes as
+long as the slowest source rather than all of them together, and are
+still applied in the order given. Use 1 to fetch them one at a time.
+*/
+func WithFetchWorkers(workers int) Option {
+	return func(o *options) {
+		o.fetchWorkers = workers
+	}
+}
+
+/*
+remoteSources returns the option sources in the order they are applied.
+*/
+func (o *options) remoteSources() []remoteSource {
+	result := []remoteSource{}
+
+	for _, source := range o.httpSources {
+		result = append(result, source)
+	}
+
+	for _, source := range o.s3Sources {
+		result = append(result, source)
+	}
+
+	for _, source := range o.redisSources {
+		result = append(result, source)
+	}
+
+	for _, source := range o.sqlSources {
+		result = append(result, source)
+	}
+
+	return result
+}
+
+/*
+applyRemoteSources fetches each source into its own set of values, at
+most workers at a time, then places them over the files on disk in
+order so later sources win. Each config document is keyed by flag name
+first.
+*/
+func applyRemoteSources(ctx context.Context, sources []remoteSource, workers int, files *container.Files, keys fileKeys) error {
+	fetched := make([]container.Files, len(sources))
+
+	err := fetchParallel(len(sources), workers, func(index int) error {
+		fetched[index] = container.Files{
+			Env: make(map[string]string),
+		}
+
+		return sources[index].apply(ctx, &fetched[index])
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, result := range fetched {
+		for key, value := range result.Env {
+			files.Env[key] = value
+		}
+
+		if result.Config, err = keys.rename(result.Config); err != nil {
+			return err
+		}
+
+		files.Config = configfile.Merge(files.Config, result.Config)
+	}
+
+	return nil
+}
+
+/*
+fetchParallel calls fetch for 0 through count-1, running at most workers
+at once. When more than one fails, the error for the lowest index is
+returned so failures are reported the same way from run to run.
+*/
+func fetchParallel(count, workers int, fetch func(index int) error) error {
+	var (
+		wg sync.WaitGroup
+	)
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	errs := make([]error, count)
+	indexes := make(chan int)
+
+	for worker := 0; worker < workers && worker < count; worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := range indexes {
+				errs[index] = fetch(index)
+			}
+		}()
+	}
+
+	for index := 0; index < count; index++ {
+		indexes <- index
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+applyDocument merges a remote document into the values read from disk.
+An env document overrides the .env file values. Any other document is
+placed over the config file.
+*/
+func applyDocument(files *container.Files, format string, data []byte) error {
+	var (
+		err    error
+		values map[string]string
+		config configfile.Values
+	)
+
+	if format == FormatEnv {
+		if values, err = env.Parse(bytes.NewReader(data)); err != nil {
+			return err
+		}
+
+		for key, value := range values {
+			files.Env[key] = value
+		}
+
+		return nil
+	}
+
+	if config, err = configfile.ParseFormat(format, data); err != nil {
+		return err
+	}
+
+	files.Config = configfile.Merge(files.Config, config)
+	return nil
+}
+
+/*
+documentFormat works out the format of a remote document from its
+content type, then the extension of its name, and finally its contents.
+*/
+func documentFormat(contentType, name string, data []byte) string {
+	contentType = strings.ToLower(contentType)
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		return configfile.FormatJSON
+
+	case strings.Contains(contentType, "yaml"):
+		return configfile.FormatYAML
+
+	case strings.Contains(contentType, "xml"):
+		return configfile.FormatXML
+	}
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".env":
+		return FormatEnv
+
+	case ".hcl":
+		return configfile.FormatHCL
+
+	case ".json", ".json5":
+		return configfile.FormatJSON
+
+	case ".tfvars":
+		return configfile.FormatTFVars
+
+	case ".xml":
+		return configfile.FormatXML
+
+	case ".yaml", ".yml":
+		return configfile.FormatYAML
+	}
+
+	return configfile.DetectFormat(data)
+}
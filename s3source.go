@@ -0,0 +1,79 @@
+package configinator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/app-nerds/configinator/configfile"
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+S3Source fetches a config document from an S3 bucket, so a fleet can
+share one centrally managed config object. The document is downloaded
+with the aws command line tool, which must be in your PATH, so
+credentials come from the standard AWS chain: environment variables,
+shared config and credentials files, and instance or task roles.
+
+Set Endpoint for S3 compatible storage, such as MinIO. Region and
+Profile are passed to the aws tool when set. Format works as it does
+for HTTPSource, and is otherwise worked out from the key's extension or
+the document itself.
+*/
+type S3Source struct {
+	Bucket   string
+	Key      string
+	Endpoint string
+	Region   string
+	Profile  string
+	Format   string
+}
+
+/*
+WithS3 fetches a config document from an S3 bucket. Env documents
+override the .env file, and other documents are placed over the config
+file. Sources are applied in the order given.
+*/
+func WithS3(source S3Source) Option {
+	return func(o *options) {
+		o.s3Sources = append(o.s3Sources, source)
+	}
+}
+
+func (s S3Source) apply(ctx context.Context, files *container.Files) error {
+	var (
+		err  error
+		data []byte
+	)
+
+	location := "s3://" + s.Bucket + "/" + s.Key
+	args := []string{"s3", "cp", location, "-"}
+
+	if s.Endpoint != "" {
+		args = append(args, "--endpoint-url", s.Endpoint)
+	}
+
+	if s.Region != "" {
+		args = append(args, "--region", s.Region)
+	}
+
+	if s.Profile != "" {
+		args = append(args, "--profile", s.Profile)
+	}
+
+	if data, err = configfile.RunToolContext(ctx, "aws", args...); err != nil {
+		return fmt.Errorf("error fetching config from %s: %w", location, err)
+	}
+
+	format := s.Format
+
+	if format == "" {
+		format = documentFormat("", s.Key, data)
+	}
+
+	if err = applyDocument(files, format, data); err != nil {
+		return fmt.Errorf("error parsing config from %s: %w", location, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package configinator
+
+import (
+	"os"
+	"path/filepath"
+)
+
+/*
+searchDirs returns the directories searched for config and env files
+when WithSearchPaths is used, most important first. These are the
+working directory, the XDG config home (~/.config/<app>), a dot
+directory in the home directory (~/.<app>), the XDG config dirs
+(/etc/xdg/<app>), and /etc/<app>.
+*/
+func searchDirs(app string) []string {
+	result := []string{"."}
+	home, err := os.UserHomeDir()
+
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		result = append(result, filepath.Join(configHome, app))
+	} else if err == nil {
+		result = append(result, filepath.Join(home, ".config", app))
+	}
+
+	if err == nil {
+		result = append(result, filepath.Join(home, "."+app))
+	}
+
+	configDirs := os.Getenv("XDG_CONFIG_DIRS")
+
+	if configDirs == "" {
+		configDirs = "/etc/xdg"
+	}
+
+	for _, dir := range filepath.SplitList(configDirs) {
+		if dir != "" {
+			result = append(result, filepath.Join(dir, app))
+		}
+	}
+
+	return append(result, filepath.Join("/etc", app))
+}
+
+/*
+searchEnvFiles places each relative env file in every search dir.
+Absolute paths are left alone. The least important dir comes first, so
+files in more important dirs override it.
+*/
+func searchEnvFiles(fileNames []string, dirs []string) []string {
+	if len(dirs) == 0 {
+		return fileNames
+	}
+
+	result := make([]string, 0, len(fileNames)*len(dirs))
+
+	for _, fileName := range fileNames {
+		if filepath.IsAbs(fileName) {
+			result = append(result, fileName)
+			continue
+		}
+
+		for index := len(dirs) - 1; index >= 0; index-- {
+			result = append(result, filepath.Join(dirs[index], fileName))
+		}
+	}
+
+	return result
+}
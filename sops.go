@@ -0,0 +1,32 @@
+package configinator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/app-nerds/configinator/configfile"
+	"github.com/app-nerds/configinator/env"
+)
+
+/*
+sopsMACKey is added by SOPS to the env files it encrypts.
+*/
+const sopsMACKey string = "sops_mac"
+
+/*
+readSOPSEnvFile decrypts an env file encrypted with SOPS, using the sops
+command line tool. The plaintext is only held in memory.
+*/
+func readSOPSEnvFile(ctx context.Context, fileName string) (map[string]string, error) {
+	var (
+		err    error
+		output []byte
+	)
+
+	if output, err = configfile.RunToolContext(ctx, "sops", "--decrypt", "--input-type", "dotenv", "--output-type", "dotenv", fileName); err != nil {
+		return nil, fmt.Errorf("error decrypting %s: %w", fileName, err)
+	}
+
+	return env.Parse(bytes.NewReader(output))
+}
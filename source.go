@@ -0,0 +1,344 @@
+package configinator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+// Names of the built in sources, as used by WithPrecedence
+const (
+	SourceDefault = container.SourceDefault
+	SourceFile    = container.SourceFile
+	SourceSecret  = container.SourceSecret
+	SourceEnvRef  = container.SourceEnvRef
+	SourceEnv     = container.SourceEnv
+	SourceEnvFile = container.SourceEnvFile
+	SourceFlag    = container.SourceFlag
+)
+
+/*
+Source is a backend config values are loaded from. Load returns values
+keyed by a field's env name, such as DB_HOST. Plug in your own with
+WithSource. The environment, .env file, and flags are Sources too, with
+the flags keyed by flag name.
+*/
+type Source interface {
+	Name() string
+	Load(ctx context.Context) (map[string]string, error)
+}
+
+/*
+Watcher is implemented by Sources that can report changes. Watch blocks
+until the context is done, calling changed whenever the source's values
+change. See WithOnChange.
+*/
+type Watcher interface {
+	Watch(ctx context.Context, changed func()) error
+}
+
+/*
+WithSource adds a Source. Its values override the .env file, and are
+overridden by flags. Sources are applied in the order given.
+*/
+func WithSource(source Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, source)
+	}
+}
+
+/*
+WithSources adds several Sources at once, in the order given.
+*/
+func WithSources(sources ...Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, sources...)
+	}
+}
+
+/*
+WithOnChange calls changed whenever a Source that is also a Watcher
+reports a change, so the app can load its config again. A Watcher that
+fails is reported as a warning. See WithWarnings.
+*/
+func WithOnChange(changed func()) Option {
+	return func(o *options) {
+		o.onChange = changed
+	}
+}
+
+/*
+WithPrecedence reorders the sources, from lowest to highest precedence,
+by name. For example, to have the environment override the .env file,
+and config file override both:
+
+	configinator.WithPrecedence(configinator.SourceEnvFile, configinator.SourceEnv, configinator.SourceFile, configinator.SourceFlag)
+
+Sources that aren't named keep their usual order, beneath those that
+are. Sources added with WithSource are named by their Name method.
+*/
+func WithPrecedence(names ...string) Option {
+	return func(o *options) {
+		o.precedence = names
+	}
+}
+
+/*
+layer is one step in resolving a field's value, such as the config file
+or the environment. Layers are applied in order, so later layers
+override earlier ones.
+*/
+type layer struct {
+	name   string
+	lookup func(c *container.Container) (string, bool, error)
+}
+
+/*
+builtinLayers returns the layers for values that aren't keyed by name
+alone, such as config files with their own keys, and secret files.
+*/
+func builtinLayers() []layer {
+	return []layer{
+		{
+			name: container.SourceDefault,
+			lookup: func(c *container.Container) (string, bool, error) {
+				value, ok := c.DefaultEnvValue()
+				return value, ok, nil
+			},
+		},
+		{
+			name: container.SourceFile,
+			lookup: func(c *container.Container) (string, bool, error) {
+				value, ok := c.FileValue()
+				return value, ok, nil
+			},
+		},
+		{
+			name: container.SourceSecret,
+			lookup: func(c *container.Container) (string, bool, error) {
+				value, ok := c.SecretValue()
+				return value, ok, nil
+			},
+		},
+		{
+			name:   container.SourceEnvRef,
+			lookup: (*container.Container).FileRefValue,
+		},
+	}
+}
+
+/*
+sourceLayer loads a Source, and returns a layer looking fields up in
+its values with lookup. The flags are looked up by flag name, and every
+other source by env name.
+*/
+func sourceLayer(ctx context.Context, source Source, lookup func(*container.Container, map[string]string) (string, bool)) (layer, error) {
+	values, err := source.Load(ctx)
+
+	if err != nil {
+		return layer{}, fmt.Errorf("error loading config from %s: %w", source.Name(), err)
+	}
+
+	return layer{
+		name: source.Name(),
+		lookup: func(c *container.Container) (string, bool, error) {
+			value, ok := lookup(c, values)
+			return value, ok, nil
+		},
+	}, nil
+}
+
+/*
+envSource is the process environment. Empty variables are kept, but
+only slice and map fields take them. See lookupEnv.
+*/
+type envSource struct{}
+
+func (envSource) Name() string {
+	return container.SourceEnv
+}
+
+func (envSource) Load(ctx context.Context) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, variable := range os.Environ() {
+		if name, value, ok := strings.Cut(variable, "="); ok {
+			result[name] = value
+		}
+	}
+
+	return result, nil
+}
+
+/*
+lookupEnv returns the raw value for a field from the environment. An
+empty variable is treated as unset, except for slice and map fields,
+where PORTS= gives an empty slice rather than the default.
+*/
+func lookupEnv(c *container.Container, values map[string]string) (string, bool) {
+	value, ok := c.Lookup(values)
+
+	if value == "" && !c.IsSlice() && !c.IsMap() {
+		return "", false
+	}
+
+	return value, ok
+}
+
+/*
+envFileSource holds the values from .env files, and remote sources
+merged with them.
+*/
+type envFileSource struct {
+	values map[string]string
+}
+
+func (s envFileSource) Name() string {
+	return container.SourceEnvFile
+}
+
+func (s envFileSource) Load(ctx context.Context) (map[string]string, error) {
+	return s.values, nil
+}
+
+/*
+flagSource holds the raw values of the flags provided on the command
+line, keyed by flag name. Fields of flag.Value types are set by the flag
+package as it parses, so they aren't included.
+*/
+type flagSource struct {
+	flags *flag.FlagSet
+}
+
+func (s flagSource) Name() string {
+	return container.SourceFlag
+}
+
+func (s flagSource) Load(ctx context.Context) (map[string]string, error) {
+	result := make(map[string]string)
+
+	s.flags.Visit(func(f *flag.Flag) {
+		if value, ok := container.RawFlagValue(f.Value); ok {
+			result[f.Name] = value
+		}
+	})
+
+	return result, nil
+}
+
+/*
+resolveLayers returns the layers in the order they are applied. The
+environment, .env file, added sources, and flags are loaded here, after
+the flags are parsed.
+*/
+func resolveLayers(ctx context.Context, o *options, files container.Files) ([]layer, error) {
+	var (
+		err error
+	)
+
+	result := builtinLayers()
+	sources := []Source{envSource{}, envFileSource{values: files.Env}}
+	sources = append(sources, o.sources...)
+	sources = append(sources, flagSource{flags: o.flags})
+
+	loaded := make([]layer, len(sources))
+
+	err = fetchParallel(len(sources), o.fetchWorkers, func(index int) (loadErr error) {
+		lookup := (*container.Container).Lookup
+
+		switch sources[index].(type) {
+		case envSource:
+			lookup = lookupEnv
+
+		case flagSource:
+			lookup = (*container.Container).LookupFlag
+		}
+
+		loaded[index], loadErr = sourceLayer(ctx, sources[index], lookup)
+		return loadErr
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	result = append(result, loaded...)
+
+	return orderLayers(result, o.precedence), nil
+}
+
+/*
+orderLayers moves the named layers to the end, in the order named, so
+they override the others.
+*/
+func orderLayers(layers []layer, names []string) []layer {
+	if len(names) == 0 {
+		return layers
+	}
+
+	named := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		named[name] = true
+	}
+
+	result := make([]layer, 0, len(layers))
+
+	for _, l := range layers {
+		if !named[l.name] {
+			result = append(result, l)
+		}
+	}
+
+	for _, name := range names {
+		for _, l := range layers {
+			if l.name == name {
+				result = append(result, l)
+			}
+		}
+	}
+
+	return result
+}
+
+/*
+watchSources starts watching the sources that are Watchers, when there
+is an OnChange callback. Watching stops when the context is done. A
+watcher that stops early with an error is reported as a warning, as
+the app would otherwise stop seeing changes without knowing why.
+*/
+func watchSources(ctx context.Context, o *options) {
+	var (
+		mutex sync.Mutex
+	)
+
+	if o.onChange == nil {
+		return
+	}
+
+	for _, source := range o.sources {
+		watcher, ok := source.(Watcher)
+
+		if !ok {
+			continue
+		}
+
+		go func(name string, watcher Watcher) {
+			err := watcher.Watch(ctx, o.onChange)
+
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			fmt.Fprintf(o.warnings, "warning: stopped watching %s: %s\n", name, err.Error())
+		}(source.Name(), watcher)
+	}
+}
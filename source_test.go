@@ -0,0 +1,92 @@
+package configinator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderLayers(t *testing.T) {
+	tests := []struct {
+		name   string
+		layers []string
+		names  []string
+		want   []string
+	}{
+		{name: "no precedence", layers: []string{"file", "env", "flag"}, want: []string{"file", "env", "flag"}},
+		{name: "one named", layers: []string{"file", "env", "flag"}, names: []string{"env"}, want: []string{"file", "flag", "env"}},
+		{name: "named in order", layers: []string{"file", "env", "vault", "flag"}, names: []string{"flag", "vault"}, want: []string{"file", "env", "flag", "vault"}},
+		{name: "every layer named", layers: []string{"file", "env", "flag"}, names: []string{"flag", "env", "file"}, want: []string{"flag", "env", "file"}},
+		{name: "unknown name", layers: []string{"file", "env"}, names: []string{"consul", "file"}, want: []string{"env", "file"}},
+		{name: "duplicate layer names", layers: []string{"custom", "env", "custom"}, names: []string{"custom"}, want: []string{"env", "custom", "custom"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			layers := make([]layer, len(test.layers))
+
+			for index, name := range test.layers {
+				layers[index] = layer{name: name}
+			}
+
+			got := make([]string, 0, len(layers))
+
+			for _, l := range orderLayers(layers, test.names) {
+				got = append(got, l.name)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+type failingWatcher struct{}
+
+func (failingWatcher) Name() string {
+	return "failing"
+}
+
+func (failingWatcher) Load(ctx context.Context) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (failingWatcher) Watch(ctx context.Context, changed func()) error {
+	return errors.New("connection lost")
+}
+
+/*
+warningWriter sends each warning written to it on a channel.
+*/
+type warningWriter chan string
+
+func (w warningWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestWatchSourcesWarns(t *testing.T) {
+	warnings := make(warningWriter, 1)
+
+	o := &options{
+		onChange: func() {},
+		sources:  []Source{failingWatcher{}},
+		warnings: warnings,
+	}
+
+	watchSources(context.Background(), o)
+
+	select {
+	case warning := <-warnings:
+		if !strings.Contains(warning, "failing") || !strings.Contains(warning, "connection lost") {
+			t.Errorf("unexpected warning %q", warning)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("expected a warning")
+	}
+}
@@ -0,0 +1,100 @@
+package configinator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+SQLSource reads key/value config rows from a database table, using any
+database/sql driver. This suits apps whose settings, such as per tenant
+settings, live in the database. Keys are matched to env names, and
+values override the .env file.
+
+By default the KeyColumn ("key") and ValueColumn ("value") columns of
+Table are read. To filter rows, such as by tenant, set Query instead,
+with Args for its placeholders in your driver's syntax. It must return
+two columns, the key and the value. Table and column names are used as
+given, so they must not come from user input.
+
+	configinator.SQLSource{
+		DB:    db,
+		Query: "SELECT name, value FROM settings WHERE tenant_id = $1",
+		Args:  []interface{}{tenantID},
+	}
+*/
+type SQLSource struct {
+	DB          *sql.DB
+	Table       string
+	KeyColumn   string
+	ValueColumn string
+	Query       string
+	Args        []interface{}
+}
+
+/*
+WithSQL reads config values from a database table. Sources are applied
+in the order given.
+*/
+func WithSQL(source SQLSource) Option {
+	return func(o *options) {
+		o.sqlSources = append(o.sqlSources, source)
+	}
+}
+
+func (s SQLSource) apply(ctx context.Context, files *container.Files) error {
+	var (
+		err   error
+		rows  *sql.Rows
+		key   string
+		value sql.NullString
+	)
+
+	if s.DB == nil {
+		return fmt.Errorf("SQL source needs a DB")
+	}
+
+	if rows, err = s.DB.QueryContext(ctx, s.query(), s.Args...); err != nil {
+		return fmt.Errorf("error reading config from the database: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = rows.Scan(&key, &value); err != nil {
+			return fmt.Errorf("error reading config from the database: %w", err)
+		}
+
+		if value.Valid {
+			files.Env[key] = value.String
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error reading config from the database: %w", err)
+	}
+
+	return nil
+}
+
+func (s SQLSource) query() string {
+	if s.Query != "" {
+		return s.Query
+	}
+
+	keyColumn := s.KeyColumn
+	valueColumn := s.ValueColumn
+
+	if keyColumn == "" {
+		keyColumn = "key"
+	}
+
+	if valueColumn == "" {
+		valueColumn = "value"
+	}
+
+	return fmt.Sprintf("SELECT %s, %s FROM %s", keyColumn, valueColumn, s.Table)
+}
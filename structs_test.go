@@ -0,0 +1,174 @@
+package configinator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNestedStructs(t *testing.T) {
+	type database struct {
+		Host string `flag:"host" env:"HOST" default:"localhost"`
+		Port int    `flag:"port" env:"PORT" default:"5432"`
+	}
+
+	type config struct {
+		Database database `prefix:"db"`
+		Replica  struct {
+			Database database `prefix:"primary"`
+		} `prefix:"replica"`
+		Cache database
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		check func(c config) bool
+	}{
+		{name: "defaults", check: func(c config) bool {
+			return c.Database.Host == "localhost" && c.Database.Port == 5432 && c.Replica.Database.Host == "localhost"
+		}},
+		{name: "prefixed flag", args: []string{"-db-host", "db.internal"}, check: func(c config) bool {
+			return c.Database.Host == "db.internal" && c.Cache.Host == "localhost"
+		}},
+		{name: "prefixed env", env: map[string]string{"DB_PORT": "6543"}, check: func(c config) bool {
+			return c.Database.Port == 6543 && c.Cache.Port == 5432
+		}},
+		{name: "combined prefixes", args: []string{"-replica-primary-host", "replica.internal"}, env: map[string]string{"REPLICA_PRIMARY_PORT": "7000"}, check: func(c config) bool {
+			return c.Replica.Database.Host == "replica.internal" && c.Replica.Database.Port == 7000 && c.Database.Host == "localhost"
+		}},
+		{name: "no prefix", args: []string{"-host", "cache.internal"}, check: func(c config) bool {
+			return c.Cache.Host == "cache.internal" && c.Database.Host == "localhost"
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			if err := beholdArgs(&got, test.args...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.check(got) {
+				t.Errorf("unexpected config %+v", got)
+			}
+		})
+	}
+}
+
+type embeddedServer struct {
+	Host string `flag:"host" env:"HOST" default:"localhost:8080"`
+}
+
+type EmbeddedLogging struct {
+	Level string `flag:"log-level" env:"LOG_LEVEL" default:"info"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	type config struct {
+		embeddedServer
+		*EmbeddedLogging
+		Debug bool `flag:"debug" env:"DEBUG"`
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		check func(c config) bool
+	}{
+		{name: "defaults", check: func(c config) bool {
+			return c.Host == "localhost:8080" && c.EmbeddedLogging != nil && c.Level == "info"
+		}},
+		{name: "promoted flags", args: []string{"-host", "0.0.0.0:80", "-log-level", "debug", "-debug"}, check: func(c config) bool {
+			return c.Host == "0.0.0.0:80" && c.Level == "debug" && c.Debug
+		}},
+		{name: "promoted env", env: map[string]string{"HOST": "example.com:443", "LOG_LEVEL": "warn"}, check: func(c config) bool {
+			return c.Host == "example.com:443" && c.Level == "warn"
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			if err := beholdArgs(&got, test.args...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.check(got) {
+				t.Errorf("unexpected config %+v", got)
+			}
+		})
+	}
+}
+
+func TestPointerFields(t *testing.T) {
+	type config struct {
+		Retries *int           `flag:"retries" env:"RETRIES"`
+		Name    *string        `flag:"name" env:"NAME"`
+		Debug   *bool          `flag:"debug"`
+		Timeout *time.Duration `flag:"timeout" default:"5s"`
+		Tags    *[]string      `flag:"tags"`
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		check func(c config) bool
+	}{
+		{name: "nil when not given", check: func(c config) bool {
+			return c.Retries == nil && c.Name == nil && c.Debug == nil && c.Tags == nil
+		}},
+		{name: "default", check: func(c config) bool {
+			return c.Timeout != nil && *c.Timeout == 5*time.Second
+		}},
+		{name: "zero value", args: []string{"-retries", "0", "-debug=false"}, check: func(c config) bool {
+			return c.Retries != nil && *c.Retries == 0 && c.Debug != nil && !*c.Debug
+		}},
+		{name: "bool flag", args: []string{"-debug"}, check: func(c config) bool {
+			return c.Debug != nil && *c.Debug
+		}},
+		{name: "env", env: map[string]string{"NAME": "api"}, check: func(c config) bool {
+			return c.Name != nil && *c.Name == "api" && c.Retries == nil
+		}},
+		{name: "slice", args: []string{"-tags", "a,b"}, check: func(c config) bool {
+			return c.Tags != nil && reflect.DeepEqual(*c.Tags, []string{"a", "b"})
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				got config
+			)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			if err := beholdArgs(&got, test.args...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.check(got) {
+				t.Errorf("unexpected config %+v", got)
+			}
+		})
+	}
+}
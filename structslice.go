@@ -0,0 +1,128 @@
+package configinator
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+isStructSlice returns true for slices of structs, or pointers to
+structs, that don't have a flag name.
+*/
+func isStructSlice(field reflect.StructField, files container.Files) bool {
+	_, hasFlag := files.LookupTag(field, container.TagFlagName)
+
+	if field.Type.Kind() != reflect.Slice || hasFlag {
+		return false
+	}
+
+	t := field.Type.Elem()
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+/*
+structSlicePrefix returns the prefix tag of a struct slice field, or the
+lower cased field name if it doesn't have one.
+*/
+func structSlicePrefix(field reflect.StructField, files container.Files) string {
+	if prefix := files.Tag(field, container.TagPrefix); prefix != "" {
+		return prefix
+	}
+
+	return strings.ToLower(field.Name)
+}
+
+/*
+setupStructSlice creates containers for each element of a slice of
+structs. Elements are addressed by index, so with a prefix of "upstreams"
+the host of the first element is set with the flag "upstreams-0-host" or
+the env variable "UPSTREAMS_0_HOST". The slice is grown to fit the
+highest index found in the config file, environment, .env file, or
+command line.
+*/
+func setupStructSlice(slice reflect.Value, prefix string, files container.Files) ([]*container.Container, error) {
+	var (
+		err        error
+		nested     []*container.Container
+		containers []*container.Container
+	)
+
+	length := structSliceLength(prefix, files)
+
+	if length > slice.Len() {
+		grown := reflect.MakeSlice(slice.Type(), length, length)
+		reflect.Copy(grown, slice)
+		slice.Set(grown)
+	}
+
+	for index := 0; index < slice.Len(); index++ {
+		element := slice.Index(index)
+
+		if element.Kind() == reflect.Ptr {
+			if element.IsNil() {
+				element.Set(reflect.New(element.Type().Elem()))
+			}
+
+			element = element.Elem()
+		}
+
+		if nested, err = setupContainers(element, joinPrefix(prefix, strconv.Itoa(index)), files); err != nil {
+			return containers, err
+		}
+
+		containers = append(containers, nested...)
+	}
+
+	return containers, nil
+}
+
+/*
+structSliceLength finds the highest index used with a struct slice prefix
+in config file keys, env variables, .env file keys, and command line
+flags, and returns the number of elements needed to hold it.
+*/
+func structSliceLength(prefix string, files container.Files) int {
+	result := 0
+
+	envPrefix := files.EnvPrefix + strings.ToUpper(strings.ReplaceAll(prefix, "-", "_")) + "_"
+	envRegex := regexp.MustCompile(`^` + regexp.QuoteMeta(envPrefix) + `(\d+)_`)
+	flagRegex := regexp.MustCompile(`^-{1,2}` + regexp.QuoteMeta(prefix) + `-(\d+)-`)
+	fileRegex := regexp.MustCompile(`^` + regexp.QuoteMeta(strings.ToLower(prefix)) + `-(\d+)-`)
+
+	check := func(regex *regexp.Regexp, value string) {
+		if matches := regex.FindStringSubmatch(value); matches != nil {
+			if index, err := strconv.Atoi(matches[1]); err == nil && index+1 > result {
+				result = index + 1
+			}
+		}
+	}
+
+	for _, variable := range os.Environ() {
+		check(envRegex, variable)
+	}
+
+	for key := range files.Env {
+		check(envRegex, key)
+	}
+
+	for _, key := range files.Config.Keys() {
+		check(fileRegex, key)
+	}
+
+	for _, arg := range files.Arguments() {
+		check(flagRegex, arg)
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+package configinator
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+/*
+DefaultTerminalWidth is the width help is wrapped to when the width of
+the terminal can't be found.
+*/
+const DefaultTerminalWidth = 80
+
+/*
+isTerminal reports whether w writes to a terminal.
+*/
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+/*
+useColor reports whether help written to w should be colored. Color is
+only used on terminals, and never when NO_COLOR is set or TERM is dumb.
+*/
+func useColor(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	return isTerminal(w)
+}
+
+/*
+terminalWidth returns the number of columns to wrap help written to w
+to. COLUMNS wins when it is set, then the size of the terminal, falling
+back to DefaultTerminalWidth.
+*/
+func terminalWidth(w io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	if file, ok := w.(*os.File); ok && isTerminal(w) {
+		if width := ttyWidth(file.Fd()); width > 0 {
+			return width
+		}
+	}
+
+	return DefaultTerminalWidth
+}
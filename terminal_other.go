@@ -0,0 +1,7 @@
+//go:build !darwin && !linux
+
+package configinator
+
+func ttyWidth(fd uintptr) int {
+	return 0
+}
@@ -0,0 +1,24 @@
+//go:build darwin || linux
+
+package configinator
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func ttyWidth(fd uintptr) int {
+	var (
+		size struct {
+			rows, cols, x, y uint16
+		}
+	)
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+
+	if errno != 0 {
+		return 0
+	}
+
+	return int(size.cols)
+}
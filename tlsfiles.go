@@ -0,0 +1,105 @@
+package configinator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+/*
+TLSFiles holds the paths to a certificate, key, and certificate authority,
+as well as a flag to skip verification. Embed it in your config as a
+nested struct, usually with a prefix. For example:
+
+	type Config struct {
+		TLS configinator.TLSFiles `prefix:"tls"`
+	}
+
+The above accepts the flags "tls-cert", "tls-key", "tls-ca", and
+"tls-insecure", or the environment variables TLS_CERT_FILE, TLS_KEY_FILE,
+TLS_CA_FILE, and TLS_INSECURE. Behold validates that the files exist
+and parse.
+*/
+type TLSFiles struct {
+	CertFile           string `flag:"cert" env:"CERT_FILE" description:"Path to a PEM encoded certificate"`
+	KeyFile            string `flag:"key" env:"KEY_FILE" description:"Path to a PEM encoded private key"`
+	CAFile             string `flag:"ca" env:"CA_FILE" description:"Path to a PEM encoded certificate authority bundle"`
+	InsecureSkipVerify bool   `flag:"insecure" env:"INSECURE" description:"Skip verification of certificates"`
+}
+
+/*
+Enabled returns true if a certificate and key were provided.
+*/
+func (t TLSFiles) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+/*
+TLSConfig builds a *tls.Config. The certificate and key are loaded if
+provided, and the certificate authority is used for both RootCAs and
+ClientCAs.
+*/
+func (t TLSFiles) TLSConfig() (*tls.Config, error) {
+	var (
+		err         error
+		certificate tls.Certificate
+		pool        *x509.CertPool
+	)
+
+	result := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.Enabled() {
+		if certificate, err = tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+			return result, fmt.Errorf("error loading TLS certificate and key: %w", err)
+		}
+
+		result.Certificates = []tls.Certificate{certificate}
+	}
+
+	if t.CAFile != "" {
+		if pool, err = t.caPool(); err != nil {
+			return result, err
+		}
+
+		result.RootCAs = pool
+		result.ClientCAs = pool
+	}
+
+	return result, nil
+}
+
+/*
+Validate checks that the certificate and key are either both provided
+or both empty, and that all provided files exist and parse.
+*/
+func (t TLSFiles) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("TLS certificate and key must be provided together")
+	}
+
+	_, err := t.TLSConfig()
+	return err
+}
+
+func (t TLSFiles) caPool() (*x509.CertPool, error) {
+	var (
+		err error
+		pem []byte
+	)
+
+	if pem, err = os.ReadFile(t.CAFile); err != nil {
+		return nil, fmt.Errorf("error reading TLS certificate authority: %w", err)
+	}
+
+	result := x509.NewCertPool()
+
+	if !result.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in TLS certificate authority '%s'", t.CAFile)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,168 @@
+package configinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+ErrRequired is the error in a FieldError for a field with a required
+tag that no source gave a value, or a required_if or required_with tag
+whose condition holds. A default tag counts as a value.
+*/
+var ErrRequired = errors.New("required, but no value was given")
+
+/*
+validateFields checks each field's value against its validation tags,
+such as min and max. The error for a field names the source of the
+value.
+*/
+func validateFields(ctx context.Context, containers []*container.Container) error {
+	for _, c := range containers {
+		if err := c.Validate(ctx); err != nil {
+			return newFieldError(c, c.Source(), err)
+		}
+	}
+
+	return nil
+}
+
+/*
+validateRequired returns an error for every required field that wasn't
+given a value, so all of them can be fixed at once. The FieldErrors are
+joined, and can be found with errors.As or matched with errors.Is.
+*/
+func validateRequired(containers []*container.Container) error {
+	var (
+		errs []error
+	)
+
+	for _, c := range containers {
+		required, condition := c.Required()
+
+		if !required || c.Source() != "" {
+			continue
+		}
+
+		if condition != "" {
+			errs = append(errs, newFieldError(c, "", fmt.Errorf("%w (%s)", ErrRequired, condition)))
+			continue
+		}
+
+		errs = append(errs, newFieldError(c, "", ErrRequired))
+	}
+
+	return errors.Join(errs...)
+}
+
+/*
+StructValidator checks a struct against its tags. It is satisfied by
+*validator.Validate from github.com/go-playground/validator, so the
+validate tags used elsewhere apply to config too.
+*/
+type StructValidator interface {
+	Struct(s interface{}) error
+}
+
+/*
+WithStructValidator runs the loaded config through a struct validator,
+such as go-playground/validator, so fields can use its validate tags:
+
+	type Config struct {
+		Endpoint string `flag:"endpoint" validate:"required,url"`
+	}
+
+	configinator.Behold(&config, configinator.WithStructValidator(validator.New()))
+
+Its error is returned by BeholdE as is, so it can be inspected with
+errors.As. It runs after the field tags are checked, and before any
+Validate methods. Make subcommand fields pointers, so the structs of
+commands that weren't given are nil, and skipped by the validator.
+*/
+func WithStructValidator(validator StructValidator) Option {
+	return func(o *options) {
+		o.validator = validator
+	}
+}
+
+/*
+Validator is implemented by config structs that check their own values
+once they are loaded, such as a rule that a TLS key is required when a
+certificate is given. BeholdE returns the error from Validate.
+Structs nested in the config are validated too, before the struct they
+are in, as are the structs in a slice of structs.
+*/
+type Validator interface {
+	Validate() error
+}
+
+/*
+validateStructs calls Validate on config and every struct in it that is
+a Validator. Errors from nested structs are prefixed with the name of
+the field holding them. Subcommand structs are left to be validated
+when their command is loaded.
+*/
+func validateStructs(config reflect.Value, files container.Files) error {
+	var (
+		err error
+	)
+
+	commands := make(map[int]bool)
+
+	for _, index := range commandFields(config, files) {
+		commands[index] = true
+	}
+
+	for index := 0; index < config.NumField(); index++ {
+		field := config.Field(index)
+
+		if commands[index] || !config.Type().Field(index).IsExported() {
+			continue
+		}
+
+		if field.Kind() == reflect.Ptr && !field.IsNil() {
+			field = field.Elem()
+		}
+
+		switch {
+		case field.Kind() == reflect.Struct:
+			err = validateStructs(field, files)
+
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct:
+			for element := 0; element < field.Len() && err == nil; element++ {
+				if err = validateStructs(field.Index(element), files); err != nil {
+					err = fmt.Errorf("%d: %w", element, err)
+				}
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", config.Type().Field(index).Name, err)
+		}
+	}
+
+	if validator, ok := validatorFor(config); ok {
+		return validator.Validate()
+	}
+
+	return nil
+}
+
+/*
+validatorFor returns the value as a Validator, whether Validate has a
+value or pointer receiver.
+*/
+func validatorFor(value reflect.Value) (Validator, bool) {
+	if value.CanAddr() {
+		if validator, ok := value.Addr().Interface().(Validator); ok {
+			return validator, true
+		}
+	}
+
+	validator, ok := value.Interface().(Validator)
+	return validator, ok
+}
@@ -0,0 +1,250 @@
+package configinator
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/app-nerds/configinator/container"
+)
+
+/*
+validationTest loads config from the command line and checks the error
+it gives, if any.
+*/
+type validationTest struct {
+	name   string
+	config interface{}
+	args   []string
+	err    error
+}
+
+func runValidationTests(t *testing.T, tests []validationTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := beholdArgs(test.config, test.args...)
+
+			if test.err == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+/*
+beholdArgs loads config with its own flag set, parsing the arguments
+given rather than os.Args.
+*/
+func beholdArgs(config interface{}, args ...string) error {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	return BeholdE(config, WithFlagSet(flags), WithArgs(args))
+}
+
+func TestRequiredTag(t *testing.T) {
+	runValidationTests(t, []validationTest{
+		{name: "missing", config: &struct {
+			Host string `flag:"host" required:"true"`
+		}{}, err: ErrRequired},
+		{name: "given", config: &struct {
+			Host string `flag:"host" required:"true"`
+		}{}, args: []string{"-host", "h"}},
+		{name: "default", config: &struct {
+			Host string `flag:"host" required:"true" default:"h"`
+		}{}},
+	})
+}
+
+func TestRangeTags(t *testing.T) {
+	runValidationTests(t, []validationTest{
+		{name: "in range", config: &struct {
+			Port int `flag:"port" min:"1" max:"65535"`
+		}{}, args: []string{"-port", "8080"}},
+		{name: "below min", config: &struct {
+			Port int `flag:"port" min:"1" max:"65535"`
+		}{}, args: []string{"-port", "0"}, err: container.ErrOutOfRange},
+		{name: "above max", config: &struct {
+			Port int `flag:"port" min:"1" max:"65535"`
+		}{}, args: []string{"-port", "70000"}, err: container.ErrOutOfRange},
+		{name: "duration above max", config: &struct {
+			Timeout time.Duration `flag:"timeout" max:"1m"`
+		}{}, args: []string{"-timeout", "2m"}, err: container.ErrOutOfRange},
+		{name: "flag.Value below min", config: &struct {
+			Listen Port `flag:"listen" min:"1024"`
+		}{}, args: []string{"-listen", "80"}, err: container.ErrOutOfRange},
+		{name: "flag.Value pointer above max", config: &struct {
+			Listen *Port `flag:"listen" max:"9000"`
+		}{}, args: []string{"-listen", "9090"}, err: container.ErrOutOfRange},
+		{name: "flag.Value in range", config: &struct {
+			Listen Port `flag:"listen" min:"1024"`
+		}{}, args: []string{"-listen", "8080"}},
+		{name: "on a string", config: &struct {
+			Name string `flag:"name" min:"1"`
+		}{}, err: container.ErrInvalid},
+	})
+}
+
+func TestPatternTag(t *testing.T) {
+	runValidationTests(t, []validationTest{
+		{name: "matches", config: &struct {
+			Slug string `flag:"slug" pattern:"^[a-z-]+$"`
+		}{}, args: []string{"-slug", "my-app"}},
+		{name: "doesn't match", config: &struct {
+			Slug string `flag:"slug" pattern:"^[a-z-]+$"`
+		}{}, args: []string{"-slug", "My App"}, err: container.ErrInvalid},
+		{name: "slice element doesn't match", config: &struct {
+			Slugs []string `flag:"slugs" pattern:"^[a-z]+$"`
+		}{}, args: []string{"-slugs", "a,B"}, err: container.ErrInvalid},
+	})
+}
+
+func TestOneOfTag(t *testing.T) {
+	runValidationTests(t, []validationTest{
+		{name: "one of", config: &struct {
+			Level string `flag:"level" oneof:"debug,info"`
+		}{}, args: []string{"-level", "info"}},
+		{name: "not one of", config: &struct {
+			Level string `flag:"level" oneof:"debug,info"`
+		}{}, args: []string{"-level", "trace"}, err: container.ErrInvalid},
+		{name: "flag.Value one of", config: &struct {
+			Level LogLevel `flag:"level" oneof:"info,error"`
+		}{}, args: []string{"-level", "error"}},
+		{name: "flag.Value not one of", config: &struct {
+			Level LogLevel `flag:"level" oneof:"info,error"`
+		}{}, args: []string{"-level", "debug"}, err: container.ErrInvalid},
+	})
+}
+
+func TestConditionalRequiredTags(t *testing.T) {
+	runValidationTests(t, []validationTest{
+		{name: "required_if holds", config: &struct {
+			TLS  bool   `flag:"tls"`
+			Cert string `flag:"cert" required_if:"TLS=true"`
+		}{}, args: []string{"-tls"}, err: ErrRequired},
+		{name: "required_if doesn't hold", config: &struct {
+			TLS  bool   `flag:"tls"`
+			Cert string `flag:"cert" required_if:"TLS=true"`
+		}{}},
+		{name: "required_with", config: &struct {
+			Cert string `flag:"cert"`
+			Key  string `flag:"key" required_with:"Cert"`
+		}{}, args: []string{"-cert", "c"}, err: ErrRequired},
+		{name: "required_with given", config: &struct {
+			Cert string `flag:"cert"`
+			Key  string `flag:"key" required_with:"Cert"`
+		}{}, args: []string{"-cert", "c", "-key", "k"}},
+	})
+}
+
+func TestPathTags(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+
+	if err := os.WriteFile(file, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	runValidationTests(t, []validationTest{
+		{name: "file exists", config: &struct {
+			Path string `flag:"path" file:"exists"`
+		}{}, args: []string{"-path", file}},
+		{name: "file missing", config: &struct {
+			Path string `flag:"path" file:"exists"`
+		}{}, args: []string{"-path", filepath.Join(dir, "missing")}, err: container.ErrInvalid},
+		{name: "file is a directory", config: &struct {
+			Path string `flag:"path" file:"exists"`
+		}{}, args: []string{"-path", dir}, err: container.ErrInvalid},
+		{name: "dir is a file", config: &struct {
+			Path string `flag:"path" dir:"exists"`
+		}{}, args: []string{"-path", file}, err: container.ErrInvalid},
+		{name: "dir created", config: &struct {
+			Path string `flag:"path" dir:"create"`
+		}{}, args: []string{"-path", filepath.Join(dir, "a", "b")}},
+	})
+
+	if info, err := os.Stat(filepath.Join(dir, "a", "b")); err != nil || !info.IsDir() {
+		t.Errorf("expected the directory to be created: %v", err)
+	}
+}
+
+func TestSchemesTag(t *testing.T) {
+	runValidationTests(t, []validationTest{
+		{name: "allowed", config: &struct {
+			URL string `flag:"url" schemes:"https"`
+		}{}, args: []string{"-url", "https://example.com"}},
+		{name: "not allowed", config: &struct {
+			URL string `flag:"url" schemes:"https"`
+		}{}, args: []string{"-url", "http://example.com"}, err: container.ErrInvalid},
+		{name: "optional not allowed", config: &struct {
+			URL Optional[string] `flag:"url" schemes:"https"`
+		}{}, args: []string{"-url", "http://example.com"}, err: container.ErrInvalid},
+		{name: "optional allowed", config: &struct {
+			URL Optional[string] `flag:"url" schemes:"https"`
+		}{}, args: []string{"-url", "https://example.com"}},
+		{name: "optional not given", config: &struct {
+			URL Optional[string] `flag:"url" schemes:"https"`
+		}{}},
+		{name: "connection URL not allowed", config: &struct {
+			DB ConnectionURL `flag:"db" schemes:"postgres"`
+		}{}, args: []string{"-db", "mysql://db/app"}, err: container.ErrInvalid},
+		{name: "connection URL pointer allowed", config: &struct {
+			DB *ConnectionURL `flag:"db" schemes:"postgres"`
+		}{}, args: []string{"-db", "postgres://db/app"}},
+	})
+}
+
+func TestBindTag(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	taken := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	runValidationTests(t, []validationTest{
+		{name: "free", config: &struct {
+			Port int `flag:"port" bind:"true"`
+		}{}, args: []string{"-port", "0"}},
+		{name: "taken", config: &struct {
+			Port int `flag:"port" bind:"true"`
+		}{}, args: []string{"-port", taken}, err: container.ErrInvalid},
+		{name: "optional taken", config: &struct {
+			Port Optional[int] `flag:"port" bind:"true"`
+		}{}, args: []string{"-port", taken}, err: container.ErrInvalid},
+		{name: "port type taken", config: &struct {
+			Port Port `flag:"port" bind:"true"`
+		}{}, args: []string{"-port", taken}, err: container.ErrInvalid},
+	})
+}
+
+func TestHostnameTag(t *testing.T) {
+	runValidationTests(t, []validationTest{
+		{name: "hostname with port", config: &struct {
+			Host string `flag:"host" hostname:"true"`
+		}{}, args: []string{"-host", "api.example.com:443"}},
+		{name: "IP address", config: &struct {
+			Host string `flag:"host" hostname:"true"`
+		}{}, args: []string{"-host", "10.0.0.1"}},
+		{name: "invalid", config: &struct {
+			Host string `flag:"host" hostname:"true"`
+		}{}, args: []string{"-host", "bad_host!"}, err: container.ErrInvalid},
+	})
+}
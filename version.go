@@ -0,0 +1,123 @@
+package configinator
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime/debug"
+)
+
+/*
+DefaultVersionFlag is the name of the flag added by WithVersion.
+*/
+const DefaultVersionFlag string = "version"
+
+/*
+ErrVersion is returned by BeholdE when the version flag is given and the
+flag set continues on errors, like flag.ErrHelp is for -help.
+*/
+var ErrVersion = errors.New("version requested")
+
+/*
+Version describes the app for the version flag. Fields left empty are
+filled from the build info embedded by the Go toolchain: the module
+path for Name, the module version for Version, and the VCS revision for
+Commit.
+*/
+type Version struct {
+	Name    string
+	Version string
+	Commit  string
+}
+
+/*
+WithVersion adds a -version flag that prints the app's name, version,
+and commit, then exits. With a flag set that continues on errors, such
+as a Loader's, ErrVersion is returned instead.
+
+	configinator.Behold(&config, configinator.WithVersion(configinator.Version{Version: version, Commit: commit}))
+*/
+func WithVersion(version Version) Option {
+	return func(o *options) {
+		o.version = &version
+	}
+}
+
+/*
+addVersionFlag defines the version flag, unless a field already uses
+the name.
+*/
+func addVersionFlag(flags *flag.FlagSet) *bool {
+	if flags.Parsed() || flags.Lookup(DefaultVersionFlag) != nil {
+		return nil
+	}
+
+	return flags.Bool(DefaultVersionFlag, false, "Print the version and exit")
+}
+
+/*
+printVersion prints the version, then exits or returns ErrVersion as
+the flag set's error handling says.
+*/
+func printVersion(w io.Writer, version Version, errorHandling flag.ErrorHandling) error {
+	fmt.Fprintln(w, version.String())
+
+	switch errorHandling {
+	case flag.ExitOnError:
+		os.Exit(0)
+	case flag.PanicOnError:
+		panic(ErrVersion)
+	}
+
+	return ErrVersion
+}
+
+/*
+String formats the version as "name version (commit)", leaving out
+parts that aren't known.
+*/
+func (v Version) String() string {
+	v = v.withBuildInfo()
+	result := v.Name
+
+	if v.Version != "" {
+		result += " " + v.Version
+	}
+
+	if v.Commit != "" {
+		result += " (" + v.Commit + ")"
+	}
+
+	return result
+}
+
+func (v Version) withBuildInfo() Version {
+	info, ok := debug.ReadBuildInfo()
+
+	if v.Name == "" {
+		v.Name = path.Base(os.Args[0])
+
+		if ok && info.Main.Path != "" {
+			v.Name = path.Base(info.Main.Path)
+		}
+	}
+
+	if !ok {
+		return v
+	}
+
+	if v.Version == "" && info.Main.Version != "(devel)" {
+		v.Version = info.Main.Version
+	}
+
+	for _, setting := range info.Settings {
+		if v.Commit == "" && setting.Key == "vcs.revision" {
+			v.Commit = setting.Value
+		}
+	}
+
+	return v
+}